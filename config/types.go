@@ -1,5 +1,7 @@
 package config
 
+import "tmux-manager/shortcuts"
+
 type Pane struct {
 	ID               string   `yaml:"id,omitempty"` // Unique identifier for this pane
 	Command          string   `yaml:"command"`
@@ -12,21 +14,26 @@ type Pane struct {
 }
 
 type Window struct {
-	Name  string `yaml:"name"`
-	Color string `yaml:"color,omitempty"` // Background color for the window tab in status bar
-	Panes []Pane `yaml:"panes"`
+	Name   string `yaml:"name"`
+	Color  string `yaml:"color,omitempty"`  // Background color for the window tab in status bar
+	Layout string `yaml:"layout,omitempty"` // tmux layout name or string applied after panes are created
+	Panes  []Pane `yaml:"panes"`
 }
 
 type TmuxConfig struct {
-	SessionName       string            `yaml:"session_name"`
-	WorkingDirectory  string            `yaml:"working_directory,omitempty"`
-	Terminal          string            `yaml:"terminal,omitempty"`
-	RcFile            string            `yaml:"rc_file,omitempty"`             // Path to RC file for shell alias (e.g., ~/.zshrc)
-	AutoColor         *bool             `yaml:"auto_color,omitempty"`          // Enable automatic color assignment (default: true)
-	ShowPaneLabels    *bool             `yaml:"show_pane_labels,omitempty"`    // Show labels on panes (default: true)
-	DefaultLabelColor string            `yaml:"default_label_color,omitempty"` // Default color for pane labels (default: blue)
-	Shortcuts         map[string]string `yaml:"shortcuts,omitempty"`           // Per-session shortcut overrides
-	DefaultShortcuts  *bool             `yaml:"default_shortcuts,omitempty"`   // Include built-in shortcuts (default: true)
-	ShortcutsFilePath string            `yaml:"-"`                             // Runtime-only: path to generated shortcuts file
-	Windows           []Window          `yaml:"windows"`
+	SessionName           string                        `yaml:"session_name"`
+	WorkingDirectory      string                        `yaml:"working_directory,omitempty"`
+	Terminal              string                        `yaml:"terminal,omitempty"`
+	RcFile                string                        `yaml:"rc_file,omitempty"`                 // Path to RC file for shell alias (e.g., ~/.zshrc)
+	AutoColor             *bool                         `yaml:"auto_color,omitempty"`              // Enable automatic color assignment (default: true)
+	ShowPaneLabels        *bool                         `yaml:"show_pane_labels,omitempty"`        // Show labels on panes (default: true)
+	DefaultLabelColor     string                        `yaml:"default_label_color,omitempty"`     // Default color for pane labels (default: blue)
+	Shortcuts             map[string]shortcuts.Shortcut `yaml:"shortcuts,omitempty"`               // Per-session shortcut overrides
+	DefaultShortcuts      *bool                         `yaml:"default_shortcuts,omitempty"`       // Include built-in shortcuts (default: true)
+	ShortcutGroups        []string                      `yaml:"shortcut_groups,omitempty"`         // Only these shortcut groups (default: all)
+	ExcludeShortcutGroups []string                      `yaml:"exclude_shortcut_groups,omitempty"` // Never these shortcut groups
+	Tags                  []string                      `yaml:"tags,omitempty"`                    // Labels copied into the registry entry
+	Protected             bool                          `yaml:"protected,omitempty"`               // Refuse to kill the session without -force
+	ShortcutsFilePath     string                        `yaml:"-"`                                 // Runtime-only: path to generated shortcuts file
+	Windows               []Window                      `yaml:"windows"`
 }
@@ -0,0 +1,220 @@
+// Package history keeps an append-only log of session lifecycle events in
+// ~/.dolly/history.jsonl, next to the registry. The registry only knows what
+// exists now; the history explains how it got there.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tmux-manager/internal/dolly"
+)
+
+// Action names what happened to a session.
+type Action string
+
+const (
+	ActionCreate     Action = "create"
+	ActionTerminate  Action = "terminate"
+	ActionAttach     Action = "attach"
+	ActionRevive     Action = "revive"
+	ActionPromote    Action = "promote"     // throwaway/exec/attached session saved as YAML
+	ActionCleanup    Action = "cleanup"     // removed by throwaway -cleanup
+	ActionSyncAdopt  Action = "sync-adopt"  // adopted by sync -adopt
+	ActionSyncRemove Action = "sync-remove" // pruned by sync as no longer running
+	ActionTag        Action = "tag"
+	ActionNote       Action = "note"
+	ActionProtect    Action = "protect"
+	ActionUnprotect  Action = "unprotect"
+	ActionShortcuts  Action = "shortcuts" // global shortcut add/remove/reset/sync
+)
+
+// The log is trimmed to its newest keepLines once it grows past maxBytes.
+const (
+	maxBytes  = 2 << 20
+	keepLines = 5000
+)
+
+// Event is one line of the history log.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Action     Action    `json:"action"`
+	Session    string    `json:"session,omitempty"`
+	Type       string    `json:"type,omitempty"`
+	ConfigFile string    `json:"config_file,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+	User       string    `json:"user,omitempty"`
+	Command    string    `json:"command,omitempty"` // dolly command line that caused the event
+}
+
+// Filter selects events in Read. Zero values match everything.
+type Filter struct {
+	Session string
+	Since   time.Time
+}
+
+func historyPath() (string, error) {
+	dir, err := dolly.DataDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine dolly data directory: %w", err)
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// lockHistory takes an exclusive lock on history.lock next to the log at
+// path and returns a function that releases it.
+func lockHistory(path string) (unlock func(), err error) {
+	return dolly.LockFile(filepath.Join(filepath.Dir(path), "history.lock"))
+}
+
+// Record appends an event to the log. Time, User and Command are filled in
+// when empty. Appends and trims hold the history lock, so concurrent dolly
+// processes neither interleave lines nor lose an event to a trim's rename.
+func Record(e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.User == "" {
+		e.User = dolly.CurrentUser()
+	}
+	if e.Command == "" {
+		e.Command = commandLine()
+	}
+
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockHistory(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open history log: %w", err)
+	}
+	_, werr := f.Write(append(line, '\n'))
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		return fmt.Errorf("could not write history log: %w", werr)
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Size() > maxBytes {
+		_ = trim(path, keepLines) // best-effort
+	}
+	return nil
+}
+
+// Read returns the events matching filter, oldest first. A missing log is an
+// empty history; malformed lines are skipped.
+func Read(filter Filter) ([]Event, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read history log: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var e Event
+		if json.Unmarshal([]byte(line), &e) != nil {
+			continue
+		}
+		if filter.Session != "" && e.Session != filter.Session {
+			continue
+		}
+		if !filter.Since.IsZero() && e.Time.Before(filter.Since) {
+			continue
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read history log: %w", err)
+	}
+	return events, nil
+}
+
+// ParseSince turns a -since value into an absolute time. It accepts Go
+// durations (90m, 12h), day and week counts (2d, 1w) and dates (2024-05-01).
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return t, nil
+	}
+	if d, err := dolly.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid -since value %q (use e.g. 2d, 1w, 12h or 2024-05-01)", s)
+}
+
+// trim rewrites the log keeping only its newest max lines. The caller holds
+// the history lock.
+func trim(path string, max int) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) <= max {
+		return nil
+	}
+	lines = lines[len(lines)-max:]
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "history-*.jsonl.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// commandLine renders the running dolly invocation, e.g. "dolly sync -adopt".
+func commandLine() string {
+	if len(os.Args) == 0 {
+		return ""
+	}
+	parts := append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...)
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,152 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupTestHome(t *testing.T) (cleanup func()) {
+	t.Helper()
+	tmp := t.TempDir()
+	orig := os.Getenv("HOME")
+	os.Setenv("HOME", tmp)
+	return func() {
+		os.Setenv("HOME", orig)
+	}
+}
+
+// ─── Record → Read round-trip ────────────────────────────────────────────────
+
+func TestRecordRead_RoundTrip(t *testing.T) {
+	defer setupTestHome(t)()
+
+	if err := Record(Event{Action: ActionCreate, Session: "api", Type: "yaml", ConfigFile: "/p/api.yml"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := Record(Event{Action: ActionSyncRemove, Session: "api", Type: "yaml", Detail: "not running"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	events, err := Read(Filter{})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	first := events[0]
+	if first.Action != ActionCreate || first.ConfigFile != "/p/api.yml" {
+		t.Errorf("unexpected first event: %+v", first)
+	}
+	if first.Time.IsZero() || first.Command == "" {
+		t.Errorf("Time and Command should be filled in: %+v", first)
+	}
+	if events[1].Action != ActionSyncRemove {
+		t.Errorf("events out of order: %+v", events)
+	}
+}
+
+func TestRead_MissingLog(t *testing.T) {
+	defer setupTestHome(t)()
+
+	events, err := Read(Filter{})
+	if err != nil || len(events) != 0 {
+		t.Fatalf("missing log should be empty history, got %v, %v", events, err)
+	}
+}
+
+// ─── Read filters ────────────────────────────────────────────────────────────
+
+func TestRead_Filters(t *testing.T) {
+	defer setupTestHome(t)()
+
+	now := time.Now()
+	Record(Event{Time: now.AddDate(0, 0, -5), Action: ActionCreate, Session: "old"})
+	Record(Event{Time: now.Add(-time.Hour), Action: ActionCreate, Session: "api"})
+	Record(Event{Time: now, Action: ActionTerminate, Session: "api"})
+
+	bySession, _ := Read(Filter{Session: "api"})
+	if len(bySession) != 2 {
+		t.Errorf("session filter: expected 2 events, got %d", len(bySession))
+	}
+
+	recent, _ := Read(Filter{Since: now.AddDate(0, 0, -2)})
+	if len(recent) != 2 {
+		t.Errorf("since filter: expected 2 events, got %d", len(recent))
+	}
+	for _, e := range recent {
+		if e.Session == "old" {
+			t.Error("since filter let an old event through")
+		}
+	}
+}
+
+func TestRead_SkipsMalformedLines(t *testing.T) {
+	defer setupTestHome(t)()
+
+	Record(Event{Action: ActionCreate, Session: "a"})
+	path, _ := historyPath()
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("{not json\n")
+	f.Close()
+	Record(Event{Action: ActionCreate, Session: "b"})
+
+	events, err := Read(Filter{})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 valid events, got %d", len(events))
+	}
+}
+
+// ─── trim keeps the newest lines ─────────────────────────────────────────────
+
+func TestTrim(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, string(rune('a'+i)))
+	}
+	os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+
+	if err := trim(path, 3); err != nil {
+		t.Fatalf("trim: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "h\ni\nj\n" {
+		t.Fatalf("expected newest 3 lines, got %q", data)
+	}
+}
+
+// ─── ParseSince ──────────────────────────────────────────────────────────────
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"":           {},
+		"2d":         now.AddDate(0, 0, -2),
+		"1w":         now.AddDate(0, 0, -7),
+		"12h":        now.Add(-12 * time.Hour),
+		"90m":        now.Add(-90 * time.Minute),
+		"2024-05-01": time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for in, want := range cases {
+		got, err := ParseSince(in, now)
+		if err != nil {
+			t.Errorf("ParseSince(%q): %v", in, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("ParseSince(%q) = %v, want %v", in, got, want)
+		}
+	}
+	for _, bad := range []string{"yesterday", "2x", "-3d", "d"} {
+		if _, err := ParseSince(bad, now); err == nil {
+			t.Errorf("ParseSince(%q) should fail", bad)
+		}
+	}
+}
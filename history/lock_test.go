@@ -0,0 +1,43 @@
+//go:build unix
+
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+// ─── Record waits for a trim in another process ──────────────────────────────
+
+func TestRecord_WaitsForLock(t *testing.T) {
+	defer setupTestHome(t)()
+	path, err := historyPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := lockHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- Record(Event{Action: ActionCreate, Session: "api"})
+	}()
+
+	select {
+	case err := <-done:
+		unlock()
+		t.Fatalf("Record appended while another process held the lock (err %v)", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	unlock()
+	if err := <-done; err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	events, err := Read(Filter{})
+	if err != nil || len(events) != 1 {
+		t.Fatalf("Read = %+v, %v", events, err)
+	}
+}
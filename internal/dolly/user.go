@@ -0,0 +1,15 @@
+package dolly
+
+import (
+	"os"
+	"os/user"
+)
+
+// CurrentUser returns the login name dolly records as the owner of
+// registry entries and the author of history events.
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
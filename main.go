@@ -3,22 +3,26 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
 	"tmux-manager/config"
+	"tmux-manager/history"
 	"tmux-manager/internal/crashlog"
 	"tmux-manager/prompt"
 	"tmux-manager/registry"
 	"tmux-manager/shortcuts"
 	"tmux-manager/throwaway"
 	"tmux-manager/tmux"
+	"tmux-manager/usage"
 )
 
 // version is injected at build time via -ldflags "-X main.version=<tag>"
@@ -31,7 +35,7 @@ func main() {
 	subcmd := "main"
 	if len(os.Args) > 1 {
 		switch os.Args[1] {
-		case "throwaway", "sessions", "attach", "sync", "shortcuts", "report":
+		case "throwaway", "sessions", "attach", "sync", "shortcuts", "report", "freeze", "revive", "tag", "note", "history", "stats", "promote", "protect", "unprotect":
 			subcmd = os.Args[1]
 		default:
 			// Detect -exec/-e flag so panics in exec mode are labelled correctly
@@ -66,11 +70,39 @@ func main() {
 		case "report":
 			handleReport(os.Args[2:])
 			return
+		case "freeze":
+			handleFreeze(os.Args[2:])
+			return
+		case "revive":
+			handleRevive(os.Args[2:])
+			return
+		case "tag":
+			handleTag(os.Args[2:])
+			return
+		case "note":
+			handleNote(os.Args[2:])
+			return
+		case "history":
+			handleHistory(os.Args[2:])
+			return
+		case "stats":
+			handleStats(os.Args[2:])
+			return
+		case "promote":
+			handlePromote(os.Args[2:])
+			return
+		case "protect":
+			handleProtect(os.Args[2:], true)
+			return
+		case "unprotect":
+			handleProtect(os.Args[2:], false)
+			return
 		}
 	}
 
 	var terminate = flag.Bool("terminate", false, "Terminate the tmux session")
 	var terminateShort = flag.Bool("t", false, "Terminate the tmux session (shorthand)")
+	var force = flag.Bool("force", false, "Terminate or recreate without the protection and busy-pane checks")
 	var help = flag.Bool("help", false, "Show help information")
 	var helpShort = flag.Bool("h", false, "Show help information (shorthand)")
 
@@ -84,6 +116,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [options] [config.yml]\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
 		fmt.Fprintf(os.Stderr, "  -terminate, -t           Terminate the tmux session\n")
+		fmt.Fprintf(os.Stderr, "  -force                   Skip protection and busy-pane checks\n")
 		fmt.Fprintf(os.Stderr, "  -exec, -e \"cmd1,cmd2\"    Create session with commands in panes\n")
 		fmt.Fprintf(os.Stderr, "  -name, -n                Session name (for -exec mode)\n")
 		fmt.Fprintf(os.Stderr, "  -help, -h                Show help information\n")
@@ -92,7 +125,16 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  sessions  [flags]        List all registered dolly sessions\n")
 		fmt.Fprintf(os.Stderr, "  attach    [SESSION|-all|-list]   Adopt existing tmux sessions\n")
 		fmt.Fprintf(os.Stderr, "  sync      [flags]                Sync registry with live tmux sessions\n")
-		fmt.Fprintf(os.Stderr, "  shortcuts [add|remove|reset|sync] Manage pane command shortcuts\n")
+		fmt.Fprintf(os.Stderr, "  shortcuts [add|remove|reset|sync|docs|check|import|bundle|trust|untrust] Manage pane command shortcuts\n")
+		fmt.Fprintf(os.Stderr, "  freeze    SESSION [-o file.yml]  Export a running session to YAML\n")
+		fmt.Fprintf(os.Stderr, "  revive    SESSION                Recreate a registered session that is no longer running\n")
+		fmt.Fprintf(os.Stderr, "  promote   SESSION [-o file.yml]  Turn a throwaway or exec session into a YAML session\n")
+		fmt.Fprintf(os.Stderr, "  protect   SESSION                Refuse to kill a session without -force\n")
+		fmt.Fprintf(os.Stderr, "  unprotect SESSION                Remove kill protection\n")
+		fmt.Fprintf(os.Stderr, "  tag       SESSION +TAG -TAG      Add or remove tags on a registered session\n")
+		fmt.Fprintf(os.Stderr, "  note      SESSION \"TEXT\"         Attach a short note to a registered session\n")
+		fmt.Fprintf(os.Stderr, "  history   [-session S] [-since 2d] Show session lifecycle events\n")
+		fmt.Fprintf(os.Stderr, "  stats     [enable|disable|-week] Show attached time, creates and lifetimes per project\n")
 		fmt.Fprintf(os.Stderr, "  report    [sub-action] [-last N] [-format table|json]\n")
 		fmt.Fprintf(os.Stderr, "            Sub-actions: submit, preview, url, mark-submitted, clear\n")
 		fmt.Fprintf(os.Stderr, "            View crash logs or open a pre-filled GitHub issue\n")
@@ -104,10 +146,12 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s throwaway                                # Instant throwaway session\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s sessions                                 # List all sessions\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s attach -list                             # Discover unmanaged sessions\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s freeze work -o work.yml                  # Save a hand-built session as YAML\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  %s -h                                       # Show help\n", os.Args[0])
 	}
 
-	flag.Parse()
+	// Flags may follow the config file too: dolly proj.yml -t -force
+	positional, _ := parseInterspersed(flag.CommandLine, os.Args[1:])
 
 	if *help || *helpShort {
 		flag.Usage()
@@ -127,24 +171,24 @@ func main() {
 
 	// Determine mode: exec mode vs config file mode
 	if execStr != "" {
-		handleExecMode(execStr, name, *terminate || *terminateShort)
+		handleExecMode(execStr, name, *terminate || *terminateShort, *force)
 		return
 	}
 
 	// Config file mode (existing logic)
-	if flag.NArg() < 1 {
+	if len(positional) < 1 {
 		flag.Usage()
 		os.Exit(1)
 	}
 
-	arg := flag.Arg(0)
+	arg := positional[0]
 
 	// If -t is set and the argument is not an existing file, treat it as a
 	// bare session name so that attached/throwaway/exec sessions can be
 	// terminated without a YAML config file.
 	if *terminate || *terminateShort {
 		if _, err := os.Stat(arg); os.IsNotExist(err) {
-			handleTerminateByName(arg)
+			handleTerminateByName(arg, *force)
 			return
 		}
 	}
@@ -153,19 +197,30 @@ func main() {
 	if err != nil {
 		crashlog.Exit(fmt.Errorf("error loading config: %v", err))
 	}
+	for _, t := range cfg.Tags {
+		if err := registry.ValidateTag(t); err != nil {
+			crashlog.Exit(fmt.Errorf("error loading config: %v", err))
+		}
+	}
 
 	if *terminate || *terminateShort {
+		guardKill(prompt.NewReader(), cfg.SessionName, *force, "terminate")
 		err = tmux.TerminateTmuxSession(cfg.SessionName, cfg.RcFile)
 		if err != nil {
 			crashlog.Fatal("main", version, fmt.Errorf("error terminating tmux session: %v", err))
 		}
 		fmt.Printf("Tmux session '%s' terminated successfully!\n", cfg.SessionName)
+		recordRemoval(history.ActionTerminate, cfg.SessionName)
 		if rerr := registry.RemoveEntry(cfg.SessionName); rerr != nil {
 			fmt.Fprintf(os.Stderr, "Note: session '%s' was not in the registry\n", cfg.SessionName)
 		}
 		return
 	}
 
+	// Creating a session replaces a running one of the same name
+	if tmux.IsSessionAlive(cfg.SessionName) {
+		guardKill(prompt.NewReader(), cfg.SessionName, *force, "recreate")
+	}
 	err = tmux.CreateTmuxSession(cfg)
 	if err != nil {
 		crashlog.Fatal("main", version, fmt.Errorf("error creating tmux session: %v", err))
@@ -183,25 +238,31 @@ func main() {
 		ConfigFile: absPath,
 		Windows:    len(cfg.Windows),
 		Terminal:   cfg.Terminal,
+		ConfigTags: cfg.Tags,
+		Protected:  cfg.Protected,
 	}); rerr != nil {
 		fmt.Fprintf(os.Stderr, "Warning: could not register session in registry: %v\n", rerr)
 	}
+	recordEvent(history.ActionCreate, cfg.SessionName, registry.TypeYAML, absPath, "")
+	installUsageHooks()
 }
 
 // handleTerminateByName terminates a tmux session by bare name (no YAML needed).
 // Used when -t is given a name that is not an existing file path.
-func handleTerminateByName(name string) {
+func handleTerminateByName(name string, force bool) {
+	guardKill(prompt.NewReader(), name, force, "terminate")
 	if err := tmux.TerminateTmuxSession(name, ""); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: could not terminate tmux session '%s': %v\n", name, err)
 	} else {
 		fmt.Printf("Tmux session '%s' terminated successfully!\n", name)
 	}
+	recordRemoval(history.ActionTerminate, name)
 	if rerr := registry.RemoveEntry(name); rerr != nil {
 		fmt.Fprintf(os.Stderr, "Note: session '%s' was not in the registry\n", name)
 	}
 }
 
-func handleExecMode(execStr, sessionName string, terminate bool) {
+func handleExecMode(execStr, sessionName string, terminate, force bool) {
 	commands := config.ParseCommands(execStr)
 	if len(commands) == 0 {
 		crashlog.Exit(fmt.Errorf("no commands provided to -exec"))
@@ -218,11 +279,13 @@ func handleExecMode(execStr, sessionName string, terminate bool) {
 	}
 
 	if terminate {
+		guardKill(reader, sessionName, force, "terminate")
 		err = tmux.TerminateTmuxSession(sessionName, "")
 		if err != nil {
 			crashlog.Fatal("exec", version, fmt.Errorf("error terminating tmux session: %v", err))
 		}
 		fmt.Printf("Tmux session '%s' terminated successfully!\n", sessionName)
+		recordRemoval(history.ActionTerminate, sessionName)
 		if rerr := registry.RemoveEntry(sessionName); rerr != nil {
 			fmt.Fprintf(os.Stderr, "Note: session '%s' was not in the registry\n", sessionName)
 		}
@@ -233,6 +296,9 @@ func handleExecMode(execStr, sessionName string, terminate bool) {
 	if err != nil {
 		crashlog.Fatal("exec", version, fmt.Errorf("error building config: %v", err))
 	}
+	if tmux.IsSessionAlive(cfg.SessionName) {
+		guardKill(reader, cfg.SessionName, force, "recreate")
+	}
 
 	err = tmux.CreateTmuxSession(cfg)
 	if err != nil {
@@ -252,6 +318,8 @@ func handleExecMode(execStr, sessionName string, terminate bool) {
 	}); rerr != nil {
 		fmt.Fprintf(os.Stderr, "Warning: could not register session in registry: %v\n", rerr)
 	}
+	recordEvent(history.ActionCreate, cfg.SessionName, registry.TypeExec, "", "")
+	installUsageHooks()
 
 	save, err := reader.ConfirmSaveConfig()
 	if err != nil {
@@ -287,8 +355,16 @@ func handleThrowaway(args []string) {
 	dir := fs.String("dir", "", "Working directory (defaults to cwd)")
 	list := fs.Bool("list", false, "List all throwaway sessions")
 	kill := fs.String("kill", "", "Kill and unregister a throwaway session by name")
-	cleanup := fs.Bool("cleanup", false, "Remove stale throwaway registry entries")
+	cleanup := fs.Bool("cleanup", false, "Kill expired throwaway sessions and remove stale registry entries")
 	days := fs.Int("days", registry.DefaultCleanupDays, "Inactivity threshold in days for -cleanup")
+	ttl := fs.String("ttl", "", "Kill the session on -cleanup once it is this old (e.g. 4h, 2d, 1w)")
+	idle := fs.String("idle", "", "Kill the session on -cleanup after this long without activity (e.g. 2h)")
+	layout := fs.String("layout", "", "Pane layout: "+strings.Join(throwaway.LayoutNames(), " | ")+" (default: side by side)")
+	cmds := fs.String("cmd", "", "Comma-separated pane commands; leave an entry empty for a plain shell")
+	preset := fs.String("preset", "", "Load defaults from ~/.dolly/presets/NAME.yml (flags override)")
+	worktree := fs.String("worktree", "", "Create a git worktree for BRANCH and start the session in it")
+	worktreeDir := fs.String("worktree-dir", "", "Parent directory for -worktree (default: "+throwaway.DefaultWorktreeDir()+")")
+	force := fs.Bool("force", false, "Let -kill, -cleanup or -name kill protected or busy sessions without asking")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: dolly throwaway [flags]\n\n")
@@ -298,10 +374,15 @@ func handleThrowaway(args []string) {
 		fmt.Fprintf(os.Stderr, "  dolly throwaway                          # instant session (2 windows, 2 panes)\n")
 		fmt.Fprintf(os.Stderr, "  dolly throwaway -windows 3 -panes 2     # custom layout\n")
 		fmt.Fprintf(os.Stderr, "  dolly throwaway -name debug              # named session\n")
+		fmt.Fprintf(os.Stderr, "  dolly throwaway -ttl 4h -idle 1h         # expire after 4h, or 1h idle\n")
+		fmt.Fprintf(os.Stderr, "  dolly throwaway -panes 3 -layout main-left -cmd \"htop,,git status\"\n")
+		fmt.Fprintf(os.Stderr, "  dolly throwaway -preset debug            # defaults from ~/.dolly/presets/debug.yml\n")
+		fmt.Fprintf(os.Stderr, "  dolly throwaway -worktree fix/login      # session in a new worktree of the current repo\n")
 		fmt.Fprintf(os.Stderr, "  dolly throwaway -list                    # list sessions\n")
 		fmt.Fprintf(os.Stderr, "  dolly throwaway -kill tw-0401-143022     # kill + unregister\n")
-		fmt.Fprintf(os.Stderr, "  dolly throwaway -cleanup                 # prune stale entries\n")
+		fmt.Fprintf(os.Stderr, "  dolly throwaway -cleanup                 # kill expired, prune stale entries\n")
 		fmt.Fprintf(os.Stderr, "  dolly throwaway -cleanup -days 14        # custom threshold\n")
+		fmt.Fprintf(os.Stderr, "  dolly throwaway -kill keep-me -force     # kill even if protected\n")
 	}
 
 	if err := fs.Parse(args); err != nil {
@@ -312,22 +393,104 @@ func handleThrowaway(args []string) {
 	case *list:
 		handleThrowawayList()
 	case *kill != "":
-		handleThrowawayKill(*kill)
+		handleThrowawayKill(*kill, *force)
 	case *cleanup:
-		handleThrowawayCleanup(*days)
+		handleThrowawayCleanup(*days, *force)
 	default:
-		handleThrowawayCreate(*name, *dir, *windows, *panes)
+		commands := throwaway.ParseCommandList(*cmds)
+		if *preset != "" {
+			p, err := throwaway.LoadPreset(*preset)
+			if err != nil {
+				crashlog.Exit(err)
+			}
+			// Preset values apply only where the flag was not given explicitly
+			set := map[string]bool{}
+			fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+			if !set["windows"] && p.Windows > 0 {
+				*windows = p.Windows
+			}
+			if !set["panes"] && p.Panes > 0 {
+				*panes = p.Panes
+			}
+			if !set["layout"] && p.Layout != "" {
+				*layout = p.Layout
+			}
+			if !set["cmd"] && len(p.Commands) > 0 {
+				commands = p.Commands
+			}
+			if !set["dir"] && p.Dir != "" {
+				*dir = expandHome(p.Dir)
+			}
+			if !set["ttl"] && p.TTL != "" {
+				*ttl = p.TTL
+			}
+			if !set["idle"] && p.Idle != "" {
+				*idle = p.Idle
+			}
+		}
+
+		ttlLimit, err := throwaway.ParseLimit(*ttl)
+		if err != nil {
+			crashlog.Exit(fmt.Errorf("invalid -ttl: %v", err))
+		}
+		idleLimit, err := throwaway.ParseLimit(*idle)
+		if err != nil {
+			crashlog.Exit(fmt.Errorf("invalid -idle: %v", err))
+		}
+		opts := throwaway.Options{
+			Name:           *name,
+			WorkingDir:     *dir,
+			Windows:        *windows,
+			PanesPerWindow: *panes,
+			Layout:         *layout,
+			Commands:       commands,
+			TTL:            ttlLimit,
+			Idle:           idleLimit,
+			Worktree:       *worktree,
+			WorktreeDir:    expandHome(*worktreeDir),
+		}
+		if err := opts.Validate(); err != nil {
+			crashlog.Exit(err)
+		}
+		if opts.Name != "" && tmux.IsSessionAlive(opts.Name) {
+			guardKill(prompt.NewReader(), opts.Name, *force, "recreate")
+		}
+		handleThrowawayCreate(opts)
+	}
+}
+
+// expandHome replaces a leading ~/ with the user's home directory.
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
 	}
+	return path
 }
 
-func handleThrowawayCreate(name, dir string, windows, panes int) {
-	created, err := throwaway.Create(name, dir, windows, panes)
+func handleThrowawayCreate(opts throwaway.Options) {
+	created, err := throwaway.Create(opts)
+	var wtErr *throwaway.WorktreeError
+	if errors.As(err, &wtErr) {
+		crashlog.Exit(err)
+	}
 	if err != nil {
 		crashlog.Fatal("throwaway", version, err)
 	}
-	fmt.Printf("Throwaway session '%s' created (%d windows, %d panes each)\n", created, windows, panes)
+	recordEvent(history.ActionCreate, created, registry.TypeThrowaway, "", "")
+	installUsageHooks()
+	fmt.Printf("Throwaway session '%s' created (%d windows, %d panes each)\n", created, opts.Windows, opts.PanesPerWindow)
+	if opts.Worktree != "" {
+		if e, ok := findEntry(created); ok && e.WorktreePath != "" {
+			fmt.Printf("Worktree: %s (branch %s)\n", e.WorktreePath, opts.Worktree)
+		}
+	}
 	fmt.Printf("Attach:  tmux attach -t %s\n", created)
 	fmt.Printf("Kill:    dolly throwaway -kill %s\n", created)
+	if opts.TTL > 0 || opts.Idle > 0 {
+		fmt.Printf("Expiry:  enforced by 'dolly throwaway -cleanup'\n")
+	}
 }
 
 func handleThrowawayList() {
@@ -341,47 +504,153 @@ func handleThrowawayList() {
 	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "NAME\tSTATUS\tWINDOWS\tLAST ACTIVE\tDIR")
+	fmt.Fprintln(w, "NAME\tSTATUS\tWINDOWS\tLAST ACTIVE\tLIMITS\tDIR")
 	for _, s := range sessions {
 		status := "dead"
 		if s.Alive {
 			status = "alive"
 		}
-		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n",
+		var limits []string
+		if s.TTL != "" {
+			limits = append(limits, "ttl "+s.TTL)
+		}
+		if s.Idle != "" {
+			limits = append(limits, "idle "+s.Idle)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n",
 			s.Name, status, s.Windows,
 			s.LastActive.Format("2006-01-02 15:04:05"),
-			s.WorkingDir,
+			orDash(strings.Join(limits, ", ")), s.WorkingDir,
 		)
 	}
 	w.Flush()
 }
 
-func handleThrowawayKill(name string) {
+func handleThrowawayKill(name string, force bool) {
+	reader := prompt.NewReader()
+	guardKill(reader, name, force, "kill")
+	entry, _ := findEntry(name)
 	if err := tmux.TerminateTmuxSession(name, ""); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: could not terminate tmux session '%s': %v\n", name, err)
 	}
+	recordRemoval(history.ActionTerminate, name)
 	if err := registry.RemoveEntry(name); err != nil {
 		crashlog.Fatal("throwaway", version, fmt.Errorf("error removing %q from registry: %v", name, err))
 	}
 	fmt.Printf("Session '%s' terminated and removed from registry.\n", name)
+	offerWorktreeRemoval(reader, entry)
 }
 
-func handleThrowawayCleanup(days int) {
+func handleThrowawayCleanup(days int, force bool) {
+	reader := prompt.NewReader()
+	expired := killExpiredThrowaways(reader, force)
+
+	// CleanupStale only reports names, so keep the entries for their worktrees
+	before, err := registry.Load()
+	if err != nil {
+		crashlog.Fatal("throwaway", version, fmt.Errorf("error loading registry: %v", err))
+	}
 	removed, err := registry.CleanupStale(days, registry.TypeThrowaway)
 	if err != nil {
 		crashlog.Fatal("throwaway", version, fmt.Errorf("error during cleanup: %v", err))
 	}
 	if len(removed) == 0 {
-		fmt.Printf("No stale throwaway sessions found (threshold: %d days).\n", days)
+		if expired == 0 {
+			fmt.Printf("No stale throwaway sessions found (threshold: %d days).\n", days)
+		}
 		return
 	}
 	for _, name := range removed {
+		recordEvent(history.ActionCleanup, name, registry.TypeThrowaway, "", fmt.Sprintf("inactive %d+ days", days))
 		fmt.Printf("Removed stale session: %s\n", name)
+		for _, e := range before.Sessions {
+			if e.Name == name {
+				offerWorktreeRemoval(reader, e)
+			}
+		}
 	}
 	fmt.Printf("Removed %d stale throwaway %s (inactive for %d+ days).\n",
 		len(removed), plural(len(removed), "entry", "entries"), days)
 }
 
+// killExpiredThrowaways terminates live throwaway sessions that are past
+// their TTL or idle limit and removes them from the registry. Protected
+// sessions, and busy ones the user does not confirm, are skipped unless force
+// is set. Returns how many were killed.
+func killExpiredThrowaways(reader *prompt.Reader, force bool) int {
+	reg, err := registry.Load()
+	if err != nil {
+		crashlog.Fatal("throwaway", version, fmt.Errorf("error loading registry: %v", err))
+	}
+	live := tmux.ListSessionActivity()
+	now := time.Now()
+
+	killed := 0
+	for _, e := range reg.Sessions {
+		if e.Type != registry.TypeThrowaway {
+			continue
+		}
+		activity, alive := live[e.Name]
+		if !alive {
+			continue
+		}
+		expired, reason := throwaway.Expired(e, activity.Activity, now)
+		if !expired {
+			continue
+		}
+		if !force && (e.Protected || tmux.IsProtected(e.Name)) {
+			fmt.Printf("Skipped protected session: %s (%s; use -force to kill it)\n", e.Name, reason)
+			continue
+		}
+		if !force && !confirmBusyPanes(reader, e.Name, "kill") {
+			fmt.Printf("Skipped busy session: %s (%s; use -force to kill it)\n", e.Name, reason)
+			continue
+		}
+		if err := tmux.TerminateTmuxSession(e.Name, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not terminate tmux session '%s': %v\n", e.Name, err)
+			continue
+		}
+		recordEvent(history.ActionCleanup, e.Name, e.Type, "", reason)
+		if err := registry.RemoveEntry(e.Name); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not remove '%s' from registry: %v\n", e.Name, err)
+		}
+		fmt.Printf("Killed expired session: %s (%s)\n", e.Name, reason)
+		offerWorktreeRemoval(reader, e)
+		killed++
+	}
+	return killed
+}
+
+// offerWorktreeRemoval asks whether to remove the git worktree a throwaway
+// session was started in. Worktrees with uncommitted changes are always kept.
+func offerWorktreeRemoval(reader *prompt.Reader, e registry.Entry) {
+	if e.WorktreePath == "" {
+		return
+	}
+	if _, err := os.Stat(e.WorktreePath); err != nil {
+		return
+	}
+	dirty, err := throwaway.WorktreeDirty(e.WorktreePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not check worktree %s: %v\n", e.WorktreePath, err)
+		return
+	}
+	if dirty {
+		fmt.Printf("Keeping worktree %s: it has uncommitted changes.\n", e.WorktreePath)
+		return
+	}
+	ok, err := reader.Confirm(fmt.Sprintf("Remove worktree %s?", e.WorktreePath))
+	if err != nil || !ok {
+		fmt.Printf("Kept worktree %s.\n", e.WorktreePath)
+		return
+	}
+	if err := throwaway.RemoveWorktree(e.WorktreeRepo, e.WorktreePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not remove worktree %s: %v\n", e.WorktreePath, err)
+		return
+	}
+	fmt.Printf("Removed worktree %s.\n", e.WorktreePath)
+}
+
 // ── attach subcommand ─────────────────────────────────────────────────────────
 
 func handleAttach(args []string) {
@@ -389,26 +658,43 @@ func handleAttach(args []string) {
 
 	all := fs.Bool("all", false, "Attach all unmanaged running tmux sessions")
 	list := fs.Bool("list", false, "List unmanaged tmux sessions (not yet in dolly registry)")
+	ignore := fs.String("ignore", "", "Add a pattern to the persistent ignore list (~/.dolly/attach-ignore)")
+	var match, exclude patternList
+	fs.Var(&match, "match", "Only adopt sessions matching this glob or /regex/ (repeatable)")
+	fs.Var(&exclude, "exclude", "Skip sessions matching this glob or /regex/ (repeatable)")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: dolly attach [SESSION | -all | -list]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: dolly attach [SESSION | -all | -list] [-match PATTERN] [-exclude PATTERN]\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nPatterns are globs (work-*) or regular expressions wrapped in slashes (/^work-\\d+$/).\n")
+		fmt.Fprintf(os.Stderr, "Sessions matching ~/.dolly/attach-ignore are never adopted in bulk.\n")
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
-		fmt.Fprintf(os.Stderr, "  dolly attach work         # adopt session named 'work'\n")
-		fmt.Fprintf(os.Stderr, "  dolly attach -all         # adopt all unmanaged sessions\n")
-		fmt.Fprintf(os.Stderr, "  dolly attach -list        # discover unmanaged sessions\n")
+		fmt.Fprintf(os.Stderr, "  dolly attach work               # adopt session named 'work'\n")
+		fmt.Fprintf(os.Stderr, "  dolly attach -all               # adopt all unmanaged sessions\n")
+		fmt.Fprintf(os.Stderr, "  dolly attach -match 'work-*'    # adopt matching sessions only\n")
+		fmt.Fprintf(os.Stderr, "  dolly attach -all -exclude ide  # adopt all except 'ide'\n")
+		fmt.Fprintf(os.Stderr, "  dolly attach -ignore 'scratch*' # never adopt scratch sessions\n")
+		fmt.Fprintf(os.Stderr, "  dolly attach -list              # discover unmanaged sessions\n")
 	}
 
 	if err := fs.Parse(args); err != nil {
 		os.Exit(1)
 	}
 
+	if *ignore != "" {
+		if err := registry.AddIgnorePattern(*ignore); err != nil {
+			crashlog.Exit(err)
+		}
+		fmt.Printf("Pattern '%s' added to the attach ignore list.\n", *ignore)
+		return
+	}
+
 	switch {
 	case *list:
-		handleAttachList()
-	case *all:
-		handleAttachAll()
+		handleAttachList(adoptionFilter("attach", match, exclude))
+	case *all || len(match) > 0:
+		handleAttachAll(adoptionFilter("attach", match, exclude))
 	case fs.NArg() >= 1:
 		handleAttachDirect(fs.Arg(0))
 	default:
@@ -453,13 +739,62 @@ func handleAttachOne(name string) (alreadyRegistered bool, err error) {
 		WorkingDir: workingDir,
 		Windows:    windows,
 		Terminal:   tmux.DetectShell(),
+		Structure:  snapshotStructure(name),
+		Protected:  tmux.IsProtected(name),
 	}); aerr != nil {
 		return alreadyRegistered, fmt.Errorf("could not update registry: %w", aerr)
 	}
+	detail := ""
+	if alreadyRegistered {
+		detail = "re-attached"
+	}
+	recordEvent(history.ActionAttach, name, registry.TypeAttached, "", detail)
+	installUsageHooks()
 
 	return alreadyRegistered, nil
 }
 
+// snapshotStructure records the window and pane structure of a live session
+// for the registry. Failure is a warning: the entry is still useful without it.
+func snapshotStructure(name string) []registry.Window {
+	snap, err := tmux.SnapshotSession(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not snapshot structure of '%s': %v\n", name, err)
+		return nil
+	}
+	windows := make([]registry.Window, 0, len(snap.Windows))
+	for _, w := range snap.Windows {
+		rw := registry.Window{Name: w.Name, Layout: w.Layout}
+		for _, p := range w.Panes {
+			rw.Panes = append(rw.Panes, registry.Pane{
+				WorkingDir: p.WorkingDir,
+				Command:    p.Command,
+				Title:      p.Title,
+			})
+		}
+		windows = append(windows, rw)
+	}
+	return windows
+}
+
+// structureSnapshot is the inverse of snapshotStructure: it turns a recorded
+// registry structure back into a tmux snapshot that can be rebuilt.
+func structureSnapshot(name string, structure []registry.Window) *tmux.SessionSnapshot {
+	snap := &tmux.SessionSnapshot{Name: name}
+	for _, w := range structure {
+		sw := tmux.WindowSnapshot{Name: w.Name, Layout: w.Layout}
+		for _, p := range w.Panes {
+			sw.Panes = append(sw.Panes, tmux.PaneSnapshot{
+				WorkingDir: p.WorkingDir,
+				Command:    p.Command,
+				Title:      p.Title,
+			})
+		}
+		snap.Windows = append(snap.Windows, sw)
+	}
+	return snap
+}
+
 func handleAttachDirect(name string) {
 	// Load registry first so we can show the previous type if already registered
 	reg, _ := registry.Load()
@@ -493,7 +828,21 @@ func handleAttachDirect(name string) {
 	fmt.Printf("Session '%s' attached to dolly (%d windows, %s)\n", name, windows, workingDir)
 }
 
-func handleAttachAll() {
+// adoptionFilter combines -match/-exclude flags with the persistent ignore
+// list. Invalid patterns are user errors.
+func adoptionFilter(subcmd string, match, exclude []string) registry.Filter {
+	ignored, err := registry.LoadIgnoreList()
+	if err != nil {
+		crashlog.Fatal(subcmd, version, err)
+	}
+	f := registry.Filter{Match: match, Exclude: exclude, Ignore: ignored}
+	if err := f.Validate(); err != nil {
+		crashlog.Exit(err)
+	}
+	return f
+}
+
+func handleAttachAll(filter registry.Filter) {
 	sessions, err := tmux.ListSessions()
 	if err != nil {
 		crashlog.Fatal("attach", version, fmt.Errorf("error listing tmux sessions: %v", err))
@@ -503,8 +852,27 @@ func handleAttachAll() {
 		return
 	}
 
-	attached, skipped := 0, 0
+	reg, err := registry.Load()
+	if err != nil {
+		crashlog.Fatal("attach", version, fmt.Errorf("error loading registry: %v", err))
+	}
+	managed := make(map[string]bool, len(reg.Sessions))
+	for _, s := range reg.Sessions {
+		managed[s.Name] = true
+	}
+
+	attached, skipped, filtered := 0, 0, 0
 	for _, name := range sessions {
+		if ok, reason := filter.Allows(name); !ok {
+			filtered++
+			fmt.Printf("  filtered '%s' (%s)\n", name, reason)
+			continue
+		}
+		if managed[name] {
+			skipped++
+			fmt.Printf("  skipped '%s' (already managed)\n", name)
+			continue
+		}
 		already, err := handleAttachOne(name)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "  error attaching '%s': %v\n", name, err)
@@ -520,6 +888,8 @@ func handleAttachAll() {
 	}
 
 	switch {
+	case attached == 0 && skipped == 0 && filtered > 0:
+		fmt.Println("No sessions matched the given filters.")
 	case attached == 0 && skipped > 0:
 		fmt.Println("All running tmux sessions are already managed by dolly.")
 	case attached > 0 && skipped > 0:
@@ -531,7 +901,7 @@ func handleAttachAll() {
 	}
 }
 
-func handleAttachList() {
+func handleAttachList(filter registry.Filter) {
 	sessions, err := tmux.ListSessions()
 	if err != nil {
 		crashlog.Fatal("attach", version, fmt.Errorf("error listing tmux sessions: %v", err))
@@ -552,7 +922,7 @@ func handleAttachList() {
 
 	var unmanaged []string
 	for _, name := range sessions {
-		if !managed[name] {
+		if ok, _ := filter.Allows(name); ok && !managed[name] {
 			unmanaged = append(unmanaged, name)
 		}
 	}
@@ -577,208 +947,1122 @@ func handleAttachList() {
 	fmt.Println(`Run "dolly attach -all" to attach all, or "dolly attach NAME" for one.`)
 }
 
-// ── sessions subcommand ───────────────────────────────────────────────────────
+// ── freeze subcommand ─────────────────────────────────────────────────────────
 
-func handleSessions(args []string) {
-	fs := flag.NewFlagSet("sessions", flag.ExitOnError)
-	typeStr := fs.String("type", "", "Filter by type: throwaway, yaml, exec, attached")
-	format := fs.String("format", "table", "Output format: table | json")
+func handleFreeze(args []string) {
+	fs := flag.NewFlagSet("freeze", flag.ExitOnError)
+	out := fs.String("o", "", "Output YAML file (default: SESSION.yml)")
+	force := fs.Bool("force", false, "Overwrite the output file if it exists")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: dolly sessions [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: dolly freeze SESSION [-o file.yml] [-force]\n\n")
+		fmt.Fprintf(os.Stderr, "Captures window names, layouts, pane working directories, running\n")
+		fmt.Fprintf(os.Stderr, "commands and pane titles of a live tmux session as a dolly YAML config.\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		fs.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
-		fmt.Fprintf(os.Stderr, "  dolly sessions                    # all registered sessions\n")
-		fmt.Fprintf(os.Stderr, "  dolly sessions -type yaml         # only YAML sessions\n")
-		fmt.Fprintf(os.Stderr, "  dolly sessions -type attached     # only attached sessions\n")
-		fmt.Fprintf(os.Stderr, "  dolly sessions -format json       # output as JSON\n")
+		fmt.Fprintf(os.Stderr, "  dolly freeze work                 # writes work.yml\n")
+		fmt.Fprintf(os.Stderr, "  dolly freeze work -o ~/work.yml   # custom output path\n")
 	}
 
+	// Pull the session name before flag parsing so flags may follow it
+	name := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name = args[0]
+		args = args[1:]
+	}
 	if err := fs.Parse(args); err != nil {
 		os.Exit(1)
 	}
-
-	var filter []registry.SessionType
-	if *typeStr != "" {
-		filter = []registry.SessionType{registry.SessionType(*typeStr)}
+	if name == "" && fs.NArg() > 0 {
+		name = fs.Arg(0)
 	}
-
-	sessions, err := registry.ListSessions(filter...)
-	if err != nil {
-		crashlog.Fatal("sessions", version, fmt.Errorf("error listing sessions: %v", err))
+	if name == "" {
+		fs.Usage()
+		os.Exit(1)
 	}
 
-	switch strings.ToLower(*format) {
-	case "json":
-		printSessionsJSON(sessions)
-	default:
-		printSessionsTable(sessions, *typeStr)
+	path := *out
+	if path == "" {
+		path = name + ".yml"
+	}
+	if _, err := os.Stat(path); err == nil && !*force {
+		crashlog.Exit(fmt.Errorf("%s already exists (use -force to overwrite)", path))
 	}
+
+	cfg := freezeSession("freeze", name, path)
+	fmt.Printf("Session '%s' frozen to '%s' (%s)\n", name, path, describeLayout(cfg))
 }
 
-func printSessionsTable(sessions []registry.SessionStatus, typeFilter string) {
-	if len(sessions) == 0 {
-		if typeFilter != "" {
-			fmt.Printf("No %s sessions registered.\n", typeFilter)
-		} else {
-			fmt.Println("No sessions registered.")
-		}
-		return
+// freezeSession snapshots a live session and writes it to path as YAML.
+// Registry tags and protection are kept so loading the YAML later re-applies
+// them.
+func freezeSession(subcmd, name, path string) *config.TmuxConfig {
+	snap, err := tmux.SnapshotSession(name)
+	if err != nil {
+		crashlog.Exit(err)
 	}
+	cfg := tmux.ConfigFromSnapshot(snap, tmux.DetectShell())
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "NAME\tTYPE\tSTATUS\tWINDOWS\tLAST ACTIVE\tCONFIG\tDIR")
-	for _, s := range sessions {
-		status := "dead"
-		if s.Alive {
-			status = "alive"
-		}
-		cfgFile := s.ConfigFile
-		if cfgFile == "" {
-			cfgFile = "-"
+	if reg, err := registry.Load(); err == nil {
+		for _, e := range reg.Sessions {
+			if e.Name == name {
+				cfg.Tags = e.Tags
+				cfg.Protected = e.Protected
+				break
+			}
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
-			s.Name, strings.ToUpper(string(s.Type)), status, s.Windows,
-			s.LastActive.Format("2006-01-02 15:04:05"),
-			cfgFile, s.WorkingDir,
-		)
-	}
-	w.Flush()
-}
-
-func printSessionsJSON(sessions []registry.SessionStatus) {
-	// Build a plain serialisable slice so Alive is included in the output.
-	type jsonEntry struct {
-		Name       string `json:"name"`
-		Type       string `json:"type"`
-		Alive      bool   `json:"alive"`
-		Windows    int    `json:"windows"`
-		WorkingDir string `json:"working_dir"`
-		ConfigFile string `json:"config_file,omitempty"`
-		Terminal   string `json:"terminal"`
-		CreatedAt  string `json:"created_at"`
-		LastActive string `json:"last_active"`
 	}
 
-	out := make([]jsonEntry, 0, len(sessions))
-	for _, s := range sessions {
-		out = append(out, jsonEntry{
-			Name:       s.Name,
-			Type:       string(s.Type),
-			Alive:      s.Alive,
-			Windows:    s.Windows,
-			WorkingDir: s.WorkingDir,
-			ConfigFile: s.ConfigFile,
-			Terminal:   s.Terminal,
-			CreatedAt:  s.CreatedAt.Format(time.RFC3339),
-			LastActive: s.LastActive.Format(time.RFC3339),
-		})
+	if err := config.SaveConfig(cfg, path); err != nil {
+		crashlog.Fatal(subcmd, version, fmt.Errorf("error saving config: %v", err))
 	}
+	return cfg
+}
 
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(out); err != nil {
-		crashlog.Fatal("sessions", version, fmt.Errorf("error encoding JSON: %v", err))
+// describeLayout renders "2 windows, 5 panes" for a config.
+func describeLayout(cfg *config.TmuxConfig) string {
+	panes := 0
+	for _, w := range cfg.Windows {
+		panes += len(w.Panes)
 	}
+	return fmt.Sprintf("%d %s, %d %s",
+		len(cfg.Windows), plural(len(cfg.Windows), "window", "windows"),
+		panes, plural(panes, "pane", "panes"))
 }
 
-// ── sync subcommand ───────────────────────────────────────────────────────────
+// ── promote subcommand ────────────────────────────────────────────────────────
 
-func handleSync(args []string) {
-	fs := flag.NewFlagSet("sync", flag.ExitOnError)
-	adopt  := fs.Bool("adopt",   false, "Also adopt running sessions not in registry")
-	dryRun := fs.Bool("dry-run", false, "Preview changes without writing")
-	format := fs.String("format", "table", "Output format: table | json")
+// handlePromote turns a throwaway, exec or attached session into a YAML
+// session: it freezes the live layout to a config file and re-registers the
+// entry as type yaml pointing at that file. Expiry limits are dropped since
+// the session is no longer disposable.
+func handlePromote(args []string) {
+	fs := flag.NewFlagSet("promote", flag.ExitOnError)
+	out := fs.String("o", "", "Output YAML file (default: SESSION.yml)")
+	force := fs.Bool("force", false, "Overwrite the output file if it exists")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: dolly sync [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: dolly promote SESSION [-o file.yml] [-force]\n\n")
+		fmt.Fprintf(os.Stderr, "Saves a running throwaway, exec or attached session as a YAML config\n")
+		fmt.Fprintf(os.Stderr, "and manages it as a YAML session from then on.\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		fs.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
-		fmt.Fprintf(os.Stderr, "  dolly sync               # prune dead registry entries\n")
-		fmt.Fprintf(os.Stderr, "  dolly sync -adopt        # prune dead + adopt unregistered sessions\n")
-		fmt.Fprintf(os.Stderr, "  dolly sync -dry-run      # preview changes\n")
-		fmt.Fprintf(os.Stderr, "  dolly sync -format json  # JSON output\n")
+		fmt.Fprintf(os.Stderr, "  dolly promote tw-0401-143022 -o api.yml   # keep a throwaway experiment\n")
+		fmt.Fprintf(os.Stderr, "  dolly promote scratch                     # writes scratch.yml\n")
 	}
 
+	// Pull the session name before flag parsing so flags may follow it
+	name := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name = args[0]
+		args = args[1:]
+	}
 	if err := fs.Parse(args); err != nil {
 		os.Exit(1)
 	}
+	if name == "" && fs.NArg() > 0 {
+		name = fs.Arg(0)
+	}
+	if name == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
 
-	// 1. Load registry once
 	reg, err := registry.Load()
 	if err != nil {
-		crashlog.Fatal("sync", version, fmt.Errorf("error loading registry: %v", err))
+		crashlog.Fatal("promote", version, fmt.Errorf("error loading registry: %v", err))
+	}
+	var entry *registry.Entry
+	for i := range reg.Sessions {
+		if reg.Sessions[i].Name == name {
+			entry = &reg.Sessions[i]
+			break
+		}
+	}
+	switch {
+	case entry == nil:
+		crashlog.Exit(fmt.Errorf("session %q is not in the registry; adopt it first with \"dolly attach %s\"", name, name))
+	case entry.Type == registry.TypeYAML:
+		crashlog.Exit(fmt.Errorf("session %q is already a YAML session (%s)", name, entry.ConfigFile))
 	}
+	fromType := entry.Type
 
-	// 2. Get all live tmux sessions
-	liveSessions, err := tmux.ListSessions()
+	path := *out
+	if path == "" {
+		path = name + ".yml"
+	}
+	if _, err := os.Stat(path); err == nil && !*force {
+		crashlog.Exit(fmt.Errorf("%s already exists (use -force to overwrite)", path))
+	}
+
+	cfg := freezeSession("promote", name, path)
+	absPath, _ := filepath.Abs(path)
+
+	err = registry.UpdateEntry(name, func(e *registry.Entry) {
+		e.Type = registry.TypeYAML
+		e.ConfigFile = absPath
+		e.WorkingDir = cfg.WorkingDirectory
+		e.Windows = len(cfg.Windows)
+		e.TTL = ""
+		e.Idle = ""
+	})
 	if err != nil {
-		crashlog.Fatal("sync", version, fmt.Errorf("error listing tmux sessions: %v", err))
+		crashlog.Fatal("promote", version, fmt.Errorf("config written to %s but registry update failed: %v", path, err))
 	}
-	liveSet := make(map[string]bool, len(liveSessions))
-	for _, s := range liveSessions {
-		liveSet[s] = true
+	recordEvent(history.ActionPromote, name, registry.TypeYAML, absPath, "from "+string(fromType))
+
+	fmt.Printf("Session '%s' promoted from %s to YAML (%s)\n", name, fromType, describeLayout(cfg))
+	fmt.Printf("Config:  %s\n", path)
+	fmt.Printf("Recreate later with: dolly %s\n", path)
+}
+
+// ── revive subcommand ─────────────────────────────────────────────────────────
+
+// handleRevive recreates a registered session that is no longer running, e.g.
+// after a tmux server restart. YAML sessions are rebuilt from their config
+// file; attached sessions from the structure recorded when they were adopted.
+func handleRevive(args []string) {
+	if len(args) != 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintf(os.Stderr, "Usage: dolly revive SESSION\n")
+		os.Exit(1)
 	}
+	name := args[0]
 
-	// 3. Compute which registry entries are dead
-	managedSet := make(map[string]bool, len(reg.Sessions))
-	var kept []registry.Entry
-	var removed []string
-	for _, entry := range reg.Sessions {
-		managedSet[entry.Name] = true
-		if liveSet[entry.Name] {
-			kept = append(kept, entry)
-		} else {
-			removed = append(removed, entry.Name)
+	reg, err := registry.Load()
+	if err != nil {
+		crashlog.Fatal("revive", version, fmt.Errorf("error loading registry: %v", err))
+	}
+	var entry *registry.Entry
+	for i := range reg.Sessions {
+		if reg.Sessions[i].Name == name {
+			entry = &reg.Sessions[i]
+			break
 		}
 	}
+	if entry == nil {
+		crashlog.Exit(fmt.Errorf("session %q is not in the registry", name))
+	}
+	if tmux.IsSessionAlive(name) {
+		crashlog.Exit(fmt.Errorf("session %q is already running", name))
+	}
 
-	// 4. Compute which live sessions are unmanaged (only if -adopt)
-	var adopted []string
-	var newEntries []registry.Entry
-	if *adopt {
-		for _, name := range liveSessions {
-			if !managedSet[name] {
-				adopted = append(adopted, name)
-				windows, workingDir, _ := tmux.GetSessionDetails(name)
-				now := time.Now()
-				newEntries = append(newEntries, registry.Entry{
-					Name:       name,
-					Type:       registry.TypeAttached,
-					CreatedAt:  now,
-					LastActive: now,
-					WorkingDir: workingDir,
-					Windows:    windows,
-					Terminal:   tmux.DetectShell(),
-				})
-			}
+	var cfg *config.TmuxConfig
+	switch {
+	case entry.ConfigFile != "":
+		cfg, err = config.LoadConfig(entry.ConfigFile)
+		if err != nil {
+			crashlog.Exit(fmt.Errorf("error loading config: %v", err))
+		}
+	case len(entry.Structure) > 0:
+		terminal := entry.Terminal
+		if terminal == "" {
+			terminal = tmux.DetectShell()
 		}
+		cfg = tmux.ConfigFromSnapshot(structureSnapshot(name, entry.Structure), terminal)
+	default:
+		crashlog.Exit(fmt.Errorf("no structure recorded for %q; re-attach it with \"dolly attach %s\" while it is running", name, name))
 	}
+	cfg.Protected = cfg.Protected || entry.Protected
 
-	// 5. Single write: build final slice and save once (no-op in dry-run)
-	if !*dryRun && (len(removed) > 0 || len(newEntries) > 0) {
-		final := append(kept, newEntries...)
-		reg.Sessions = final
-		if serr := registry.Save(reg); serr != nil {
-			crashlog.Fatal("sync", version, fmt.Errorf("error saving registry: %v", serr))
-		}
+	if err := tmux.CreateTmuxSession(cfg); err != nil {
+		crashlog.Fatal("revive", version, fmt.Errorf("error creating tmux session: %v", err))
 	}
 
-	// 6. Output
-	if strings.ToLower(*format) == "json" {
-		printSyncJSON(removed, adopted, *dryRun)
-	} else {
-		printSyncTable(removed, adopted, *dryRun)
+	revived := *entry
+	revived.LastActive = time.Now()
+	if rerr := registry.AddEntry(revived); rerr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not update registry: %v\n", rerr)
 	}
+	recordEvent(history.ActionRevive, name, entry.Type, entry.ConfigFile, "")
+	installUsageHooks()
+	fmt.Printf("Session '%s' revived (%d %s)\n", name, len(cfg.Windows), plural(len(cfg.Windows), "window", "windows"))
 }
 
-func printSyncTable(removed, adopted []string, dryRun bool) {
-	if len(removed) == 0 && len(adopted) == 0 {
-		fmt.Println("Registry is already in sync with live tmux sessions.")
-		return
-	}
+// ── sessions subcommand ───────────────────────────────────────────────────────
+
+func handleSessions(args []string) {
+	fs := flag.NewFlagSet("sessions", flag.ExitOnError)
+	typeStr := fs.String("type", "", "Filter by type: throwaway, yaml, exec, attached")
+	format := fs.String("format", "table", "Output format: table | json")
+	tree := fs.Bool("tree", false, "Show the recorded windows and panes of each session")
+	tagStr := fs.String("tag", "", "Only show sessions with this tag (comma-separated: all must match)")
+	verbose := fs.Bool("v", false, "Also list panes running something other than an idle shell")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dolly sessions [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  dolly sessions                    # all registered sessions\n")
+		fmt.Fprintf(os.Stderr, "  dolly sessions -type yaml         # only YAML sessions\n")
+		fmt.Fprintf(os.Stderr, "  dolly sessions -type attached     # only attached sessions\n")
+		fmt.Fprintf(os.Stderr, "  dolly sessions -format json       # output as JSON\n")
+		fmt.Fprintf(os.Stderr, "  dolly sessions -tree              # show windows and panes\n")
+		fmt.Fprintf(os.Stderr, "  dolly sessions -tag backend       # only sessions tagged backend\n")
+		fmt.Fprintf(os.Stderr, "  dolly sessions -v                 # show busy panes of running sessions\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	var filter []registry.SessionType
+	if *typeStr != "" {
+		filter = []registry.SessionType{registry.SessionType(*typeStr)}
+	}
+
+	sessions, err := registry.ListSessions(filter...)
+	if err != nil {
+		crashlog.Fatal("sessions", version, fmt.Errorf("error listing sessions: %v", err))
+	}
+
+	emptyLabel := *typeStr
+	if *tagStr != "" {
+		tags := splitTags(*tagStr)
+		tagged := sessions[:0]
+		for _, s := range sessions {
+			if s.HasTags(tags) {
+				tagged = append(tagged, s)
+			}
+		}
+		sessions = tagged
+		emptyLabel = strings.TrimSpace(emptyLabel + " " + strings.Join(tags, "+") + "-tagged")
+	}
+
+	var busy map[string][]tmux.BusyPane
+	if *verbose {
+		busy = map[string][]tmux.BusyPane{}
+		for _, s := range sessions {
+			if !s.Alive {
+				continue
+			}
+			panes, err := tmux.BusyPanes(s.Name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				continue
+			}
+			if len(panes) > 0 {
+				busy[s.Name] = panes
+			}
+		}
+	}
+
+	switch {
+	case strings.ToLower(*format) == "json":
+		printSessionsJSON(sessions, busy)
+		return
+	case *tree:
+		printSessionsTree(sessions, emptyLabel)
+	default:
+		printSessionsTable(sessions, emptyLabel)
+	}
+	for _, s := range sessions {
+		if panes := busy[s.Name]; len(panes) > 0 {
+			fmt.Println()
+			printBusyPanes(s.Name, panes)
+		}
+	}
+}
+
+// printSessionsTree lists each session followed by its recorded windows and
+// panes. Sessions without a recorded structure show their window count only.
+func printSessionsTree(sessions []registry.SessionStatus, typeFilter string) {
+	if len(sessions) == 0 {
+		printSessionsTable(sessions, typeFilter)
+		return
+	}
+
+	for i, s := range sessions {
+		if i > 0 {
+			fmt.Println()
+		}
+		status := "dead"
+		if s.Alive {
+			status = "alive"
+		}
+		if s.Protected {
+			status += ", protected"
+		}
+		fmt.Printf("%s (%s, %s)\n", s.Name, strings.ToUpper(string(s.Type)), status)
+		if len(s.Tags) > 0 {
+			fmt.Printf("   tags: %s\n", strings.Join(s.Tags, ", "))
+		}
+		if s.Note != "" {
+			fmt.Printf("   note: %s\n", s.Note)
+		}
+		if len(s.Structure) == 0 {
+			fmt.Printf("└─ %d %s (structure not recorded)\n", s.Windows, plural(s.Windows, "window", "windows"))
+			continue
+		}
+		for wi, w := range s.Structure {
+			lastWindow := wi == len(s.Structure)-1
+			branch, indent := "├─", "│  "
+			if lastWindow {
+				branch, indent = "└─", "   "
+			}
+			fmt.Printf("%s %s\n", branch, w.Name)
+			for pi, p := range w.Panes {
+				paneBranch := "├─"
+				if pi == len(w.Panes)-1 {
+					paneBranch = "└─"
+				}
+				cmd := p.Command
+				if cmd == "" {
+					cmd = "(shell)"
+				}
+				fmt.Printf("%s%s %s  %s\n", indent, paneBranch, p.WorkingDir, cmd)
+			}
+		}
+	}
+}
+
+func printSessionsTable(sessions []registry.SessionStatus, typeFilter string) {
+	if len(sessions) == 0 {
+		if typeFilter != "" {
+			fmt.Printf("No %s sessions registered.\n", typeFilter)
+		} else {
+			fmt.Println("No sessions registered.")
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tSTATUS\tWINDOWS\tLAST ACTIVE\tTAGS\tCONFIG\tDIR")
+	for _, s := range sessions {
+		status := "dead"
+		if s.Alive {
+			status = "alive"
+		}
+		if s.Protected {
+			status += " (protected)"
+		}
+		cfgFile := s.ConfigFile
+		if cfgFile == "" {
+			cfgFile = "-"
+		}
+		tags := strings.Join(s.Tags, ",")
+		if tags == "" {
+			tags = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			s.Name, strings.ToUpper(string(s.Type)), status, s.Windows,
+			s.LastActive.Format("2006-01-02 15:04:05"),
+			tags, cfgFile, s.WorkingDir,
+		)
+	}
+	w.Flush()
+}
+
+func printSessionsJSON(sessions []registry.SessionStatus, busy map[string][]tmux.BusyPane) {
+	// Build a plain serialisable slice so Alive is included in the output.
+	type jsonBusyPane struct {
+		Window  string `json:"window"`
+		Pane    int    `json:"pane"`
+		Command string `json:"command"`
+	}
+	type jsonEntry struct {
+		Name       string            `json:"name"`
+		Type       string            `json:"type"`
+		Alive      bool              `json:"alive"`
+		Windows    int               `json:"windows"`
+		WorkingDir string            `json:"working_dir"`
+		ConfigFile string            `json:"config_file,omitempty"`
+		Terminal   string            `json:"terminal"`
+		CreatedAt  string            `json:"created_at"`
+		LastActive string            `json:"last_active"`
+		Structure  []registry.Window `json:"structure,omitempty"`
+		Tags       []string          `json:"tags,omitempty"`
+		Note       string            `json:"note,omitempty"`
+		Owner      string            `json:"owner,omitempty"`
+		Protected  bool              `json:"protected,omitempty"`
+		BusyPanes  []jsonBusyPane    `json:"busy_panes,omitempty"` // only with -v
+	}
+
+	out := make([]jsonEntry, 0, len(sessions))
+	for _, s := range sessions {
+		out = append(out, jsonEntry{
+			Name:       s.Name,
+			Type:       string(s.Type),
+			Alive:      s.Alive,
+			Windows:    s.Windows,
+			WorkingDir: s.WorkingDir,
+			ConfigFile: s.ConfigFile,
+			Terminal:   s.Terminal,
+			CreatedAt:  s.CreatedAt.Format(time.RFC3339),
+			LastActive: s.LastActive.Format(time.RFC3339),
+			Structure:  s.Structure,
+			Tags:       s.Tags,
+			Note:       s.Note,
+			Owner:      s.Owner,
+			Protected:  s.Protected,
+		})
+		for _, b := range busy[s.Name] {
+			out[len(out)-1].BusyPanes = append(out[len(out)-1].BusyPanes, jsonBusyPane(b))
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		crashlog.Fatal("sessions", version, fmt.Errorf("error encoding JSON: %v", err))
+	}
+}
+
+// ── protect subcommand ────────────────────────────────────────────────────────
+
+// handleProtect sets or clears kill protection on a registered session. The
+// flag is stored on the registry entry and, when the session is running, as a
+// tmux user option so the marker is visible to tmux itself.
+func handleProtect(args []string, protect bool) {
+	cmdName := "protect"
+	action := history.ActionProtect
+	if !protect {
+		cmdName = "unprotect"
+		action = history.ActionUnprotect
+	}
+	if len(args) != 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintf(os.Stderr, "Usage: dolly %s SESSION\n\n", cmdName)
+		fmt.Fprintf(os.Stderr, "Protected sessions are not killed by -t, throwaway -kill or -cleanup\n")
+		fmt.Fprintf(os.Stderr, "unless -force is given.\n")
+		os.Exit(1)
+	}
+	name := args[0]
+
+	var typ registry.SessionType
+	err := registry.UpdateEntry(name, func(e *registry.Entry) {
+		e.Protected = protect
+		typ = e.Type
+	})
+	if err != nil {
+		crashlog.Exit(fmt.Errorf("%v; adopt it first with \"dolly attach %s\"", err, name))
+	}
+	if tmux.IsSessionAlive(name) {
+		if err := tmux.SetProtected(name, protect); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	recordEvent(action, name, typ, "", "")
+
+	if protect {
+		fmt.Printf("Session '%s' is protected; killing it now requires -force.\n", name)
+	} else {
+		fmt.Printf("Session '%s' is no longer protected.\n", name)
+	}
+}
+
+// isProtected reports whether either the registry entry or the live tmux
+// session carries the protection marker.
+func isProtected(name string) bool {
+	if e, ok := findEntry(name); ok && e.Protected {
+		return true
+	}
+	return tmux.IsProtected(name)
+}
+
+// guardKill stops kills the user did not mean: a protected session needs
+// force, and one with busy panes needs confirmation unless force is set.
+func guardKill(reader *prompt.Reader, name string, force bool, verb string) {
+	refuseIfProtected(name, force, verb)
+	if !force && !confirmBusyPanes(reader, name, verb) {
+		crashlog.Exit(fmt.Errorf("session %q left running; use -force to %s it without asking", name, verb))
+	}
+}
+
+// confirmBusyPanes lists the panes of name that are running something other
+// than an idle shell and asks whether to go ahead. EOF on stdin counts as no,
+// so scripts must pass -force. Returns true when no pane is busy.
+func confirmBusyPanes(reader *prompt.Reader, name, verb string) bool {
+	busy, err := tmux.BusyPanes(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not inspect panes: %v\n", err)
+		return true
+	}
+	if len(busy) == 0 {
+		return true
+	}
+	printBusyPanes(name, busy)
+	ok, err := reader.Confirm(fmt.Sprintf("Really %s '%s'?", verb, name))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return ok
+}
+
+// printBusyPanes prints the busy-pane summary shared by kill confirmations
+// and `dolly sessions -v`.
+func printBusyPanes(name string, busy []tmux.BusyPane) {
+	fmt.Printf("Session '%s' has %d busy %s:\n", name, len(busy), plural(len(busy), "pane", "panes"))
+	for _, b := range busy {
+		fmt.Printf("  %s\n", b)
+	}
+}
+
+// refuseIfProtected exits with an error when name is protected and force was
+// not given. verb describes what would have happened, e.g. "terminate".
+func refuseIfProtected(name string, force bool, verb string) {
+	if force || !isProtected(name) {
+		return
+	}
+	crashlog.Exit(fmt.Errorf("session %q is protected; use -force to %s it anyway, or run \"dolly unprotect %s\"", name, verb, name))
+}
+
+// ── tag subcommand ────────────────────────────────────────────────────────────
+
+// handleTag adds (+tag) and removes (-tag) tags on a registry entry. Arguments
+// are parsed by hand because -tag would otherwise be read as a flag. With no
+// changes it prints the session's current tags.
+func handleTag(args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: dolly tag SESSION [+TAG | -TAG]...\n\n")
+		fmt.Fprintf(os.Stderr, "A bare TAG is the same as +TAG.\n")
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  dolly tag api +backend -urgent   # add backend, remove urgent\n")
+		fmt.Fprintf(os.Stderr, "  dolly tag api                    # show tags\n")
+		fmt.Fprintf(os.Stderr, "  dolly sessions -tag backend      # list sessions tagged backend\n")
+	}
+	isHelp := func(a string) bool { return a == "-h" || a == "-help" || a == "--help" }
+	if len(args) < 1 || isHelp(args[0]) {
+		usage()
+		if len(args) < 1 {
+			os.Exit(1)
+		}
+		return
+	}
+	if strings.HasPrefix(args[0], "-") {
+		crashlog.Exit(fmt.Errorf("expected a session name before the tags, got %q (see dolly tag -h)", args[0]))
+	}
+
+	name := args[0]
+	var add, remove []string
+	for _, a := range args[1:] {
+		if isHelp(a) {
+			usage()
+			return
+		}
+		if strings.HasPrefix(a, "--") {
+			crashlog.Exit(fmt.Errorf("unknown option %q: remove a tag with a single dash, e.g. -urgent", a))
+		}
+		tag := a
+		if strings.HasPrefix(a, "+") || strings.HasPrefix(a, "-") {
+			tag = a[1:]
+		}
+		if err := registry.ValidateTag(tag); err != nil {
+			crashlog.Exit(err)
+		}
+		if strings.HasPrefix(a, "-") {
+			remove = append(remove, tag)
+		} else {
+			add = append(add, tag)
+		}
+	}
+
+	var tags []string
+	var typ registry.SessionType
+	err := registry.UpdateEntry(name, func(e *registry.Entry) {
+		e.Tags = registry.ApplyTags(e.Tags, add, remove)
+		tags, typ = e.Tags, e.Type
+	})
+	if err != nil {
+		crashlog.Exit(err)
+	}
+	if len(add)+len(remove) > 0 {
+		recordEvent(history.ActionTag, name, typ, "", strings.Join(args[1:], " "))
+	}
+
+	if len(tags) == 0 {
+		fmt.Printf("Session '%s' has no tags.\n", name)
+		return
+	}
+	fmt.Printf("Session '%s' tags: %s\n", name, strings.Join(tags, ", "))
+}
+
+// splitTags parses a comma-separated -tag value.
+func splitTags(s string) []string {
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// ── note subcommand ───────────────────────────────────────────────────────────
+
+// handleNote sets, clears or prints the note on a registry entry.
+func handleNote(args []string) {
+	fs := flag.NewFlagSet("note", flag.ExitOnError)
+	clearNote := fs.Bool("clear", false, "Remove the note")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dolly note SESSION [TEXT | -clear]\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  dolly note api \"payments refactor, see PR 412\"   # set the note\n")
+		fmt.Fprintf(os.Stderr, "  dolly note api                                    # show the note\n")
+		fmt.Fprintf(os.Stderr, "  dolly note api -clear                             # remove the note\n")
+	}
+
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		fs.Parse(args)
+		fs.Usage()
+		os.Exit(1)
+	}
+	name := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		os.Exit(1)
+	}
+	text := strings.TrimSpace(strings.Join(fs.Args(), " "))
+
+	var note string
+	var typ registry.SessionType
+	err := registry.UpdateEntry(name, func(e *registry.Entry) {
+		switch {
+		case *clearNote:
+			e.Note = ""
+		case text != "":
+			e.Note = text
+		}
+		note, typ = e.Note, e.Type
+	})
+	if err != nil {
+		crashlog.Exit(err)
+	}
+	switch {
+	case *clearNote:
+		recordEvent(history.ActionNote, name, typ, "", "cleared")
+	case text != "":
+		recordEvent(history.ActionNote, name, typ, "", text)
+	}
+
+	switch {
+	case *clearNote:
+		fmt.Printf("Note removed from '%s'.\n", name)
+	case note == "":
+		fmt.Printf("Session '%s' has no note.\n", name)
+	case text != "":
+		fmt.Printf("Note saved on '%s'.\n", name)
+	default:
+		fmt.Println(note)
+	}
+}
+
+// ── history subcommand ────────────────────────────────────────────────────────
+
+func handleHistory(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	session := fs.String("session", "", "Only show events for this session")
+	since := fs.String("since", "", "Only show events newer than this (2d, 1w, 12h or 2006-01-02)")
+	format := fs.String("format", "table", "Output format: table | json")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dolly history [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Shows create, terminate, attach, cleanup, sync and shortcut events\n")
+		fmt.Fprintf(os.Stderr, "recorded in ~/.dolly/history.jsonl, oldest first.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  dolly history                     # full history\n")
+		fmt.Fprintf(os.Stderr, "  dolly history -session api        # what happened to 'api'\n")
+		fmt.Fprintf(os.Stderr, "  dolly history -since 2d           # last two days\n")
+		fmt.Fprintf(os.Stderr, "  dolly history -format json        # output as JSON\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	sinceTime, err := history.ParseSince(*since, time.Now())
+	if err != nil {
+		crashlog.Exit(err)
+	}
+	events, err := history.Read(history.Filter{Session: *session, Since: sinceTime})
+	if err != nil {
+		crashlog.Fatal("history", version, err)
+	}
+
+	if strings.ToLower(*format) == "json" {
+		if events == nil {
+			events = []history.Event{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(events); err != nil {
+			crashlog.Fatal("history", version, fmt.Errorf("error encoding JSON: %v", err))
+		}
+		return
+	}
+
+	if len(events) == 0 {
+		fmt.Println("No history recorded.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tACTION\tSESSION\tTYPE\tUSER\tDETAIL\tCOMMAND")
+	for _, e := range events {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Local().Format("2006-01-02 15:04:05"), e.Action,
+			orDash(e.Session), orDash(strings.ToUpper(e.Type)), orDash(e.User),
+			orDash(e.Detail), orDash(e.Command),
+		)
+	}
+	w.Flush()
+}
+
+// ── stats subcommand ──────────────────────────────────────────────────────────
+
+func handleStats(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "sample":
+			// Invoked by the tmux hooks from installUsageHooks. Any output
+			// would pop up in the user's pane, so failures only set the exit
+			// status. Hooks left behind by a disabled dolly do nothing.
+			if usage.Enabled() {
+				if err := sampleUsage(); err != nil {
+					os.Exit(1)
+				}
+			}
+			return
+		case "enable":
+			handleStatsEnable(true)
+			return
+		case "disable":
+			handleStatsEnable(false)
+			return
+		}
+	}
+
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	week := fs.Bool("week", false, "Only the last 7 days (same as -since 1w)")
+	since := fs.String("since", "", "Only time after this (2d, 1w, 12h or 2006-01-02)")
+	by := fs.String("by", "project", "Group by: project (config file, else session name) | session")
+	format := fs.String("format", "table", "Output format: table | json")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dolly stats [flags]\n")
+		fmt.Fprintf(os.Stderr, "       dolly stats enable | disable\n\n")
+		fmt.Fprintf(os.Stderr, "Attached time is recorded by global tmux hooks once tracking is turned\n")
+		fmt.Fprintf(os.Stderr, "on with `dolly stats enable`; `dolly stats disable` removes them again.\n")
+		fmt.Fprintf(os.Stderr, "Create counts and lifetimes come from dolly history.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  dolly stats -week                 # hours per project this week\n")
+		fmt.Fprintf(os.Stderr, "  dolly stats -since 2024-05-01     # since a date\n")
+		fmt.Fprintf(os.Stderr, "  dolly stats -by session           # per session instead of project\n")
+		fmt.Fprintf(os.Stderr, "  dolly stats -format json          # output as JSON\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if *by != "project" && *by != "session" {
+		crashlog.Exit(fmt.Errorf("invalid -by value %q (use project or session)", *by))
+	}
+
+	now := time.Now()
+	sinceStr := *since
+	if *week && sinceStr == "" {
+		sinceStr = "1w"
+	}
+	sinceTime, err := history.ParseSince(sinceStr, now)
+	if err != nil {
+		crashlog.Exit(err)
+	}
+
+	// Bring open intervals up to date so attached sessions count until now
+	// and detached or dead ones stop accruing time.
+	if usage.Enabled() {
+		if err := sampleUsage(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not sample usage: %v\n", err)
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "Usage tracking is off, so attached time is not recorded; run `dolly stats enable` to turn it on.\n")
+	}
+
+	intervals, err := usage.Intervals(sinceTime, now)
+	if err != nil {
+		crashlog.Fatal("stats", version, err)
+	}
+	events, err := history.Read(history.Filter{})
+	if err != nil {
+		crashlog.Fatal("stats", version, err)
+	}
+
+	keyOf := func(session, configFile string) string {
+		if *by == "project" && configFile != "" {
+			return strings.TrimSuffix(filepath.Base(configFile), filepath.Ext(configFile))
+		}
+		return session
+	}
+	rows := usage.Summarize(intervals, events, sinceTime, keyOf)
+
+	if strings.ToLower(*format) == "json" {
+		printStatsJSON(rows, *by)
+		return
+	}
+	printStatsTable(rows, *by)
+}
+
+// sampleUsage records which sessions currently have clients attached and
+// refreshes registry LastActive from tmux activity. It runs under the
+// registry lock, which also serializes the usage state files.
+func sampleUsage() error {
+	live := tmux.ListSessionActivity()
+	return registry.Update(func(reg *registry.Registry) error {
+		configs := make(map[string]string, len(reg.Sessions))
+		for _, e := range reg.Sessions {
+			configs[e.Name] = e.ConfigFile
+		}
+		obs := make(map[string]usage.Observation, len(live))
+		activity := make(map[string]time.Time, len(live))
+		for name, a := range live {
+			obs[name] = usage.Observation{Attached: a.Clients > 0, ConfigFile: configs[name]}
+			activity[name] = a.Activity
+		}
+		registry.RecordActivity(reg, activity)
+		return usage.Sample(time.Now(), obs)
+	})
+}
+
+// handleStatsEnable turns usage tracking on or off. Disabling removes the
+// hooks and closes the open intervals, so nothing accrues while tracking is
+// off.
+func handleStatsEnable(on bool) {
+	if !on {
+		if err := usage.SetEnabled(false); err != nil {
+			crashlog.Exit(err)
+		}
+		if err := tmux.UninstallUsageHooks(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		// Sample first so sessions attached right now are counted up to now
+		_ = sampleUsage()
+		err := registry.Update(func(reg *registry.Registry) error {
+			return usage.CloseAll(time.Now())
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not close open usage intervals: %v\n", err)
+		}
+		fmt.Println("Usage tracking disabled; dolly's tmux hooks were removed. Recorded time is kept.")
+		return
+	}
+
+	if err := usage.SetEnabled(true); err != nil {
+		crashlog.Exit(err)
+	}
+	installUsageHooks()
+	if err := sampleUsage(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not sample usage: %v\n", err)
+	}
+	fmt.Println("Usage tracking enabled. dolly installs global tmux hooks (client-attached, client-detached,")
+	fmt.Println("session-closed) when it creates or attaches a session; `dolly stats disable` removes them.")
+}
+
+// installUsageHooks points tmux's attach/detach hooks at "dolly stats
+// sample" once tracking is enabled. Usage tracking is optional, so failures
+// are ignored.
+func installUsageHooks() {
+	if !usage.Enabled() {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	_ = tmux.InstallUsageHooks(exe)
+}
+
+func printStatsTable(rows []usage.Row, by string) {
+	if len(rows) == 0 {
+		fmt.Println("No usage recorded yet.")
+		return
+	}
+
+	var total time.Duration
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tATTACHED\tSESSIONS\tCREATED\tAVG LIFETIME\n", strings.ToUpper(by))
+	for _, r := range rows {
+		lifetime := "-"
+		if r.Ended > 0 {
+			lifetime = formatDuration(r.Lifetime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", r.Key, formatDuration(r.Attached), r.Sessions, r.Created, lifetime)
+		total += r.Attached
+	}
+	w.Flush()
+	fmt.Printf("\nTotal attached: %s\n", formatDuration(total))
+}
+
+func printStatsJSON(rows []usage.Row, by string) {
+	type jsonRow struct {
+		Key                string  `json:"key"`
+		GroupBy            string  `json:"group_by"`
+		AttachedHours      float64 `json:"attached_hours"`
+		Sessions           int     `json:"sessions"`
+		Created            int     `json:"created"`
+		Ended              int     `json:"ended"`
+		AvgLifetimeSeconds int64   `json:"avg_lifetime_seconds,omitempty"`
+	}
+
+	out := make([]jsonRow, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, jsonRow{
+			Key:                r.Key,
+			GroupBy:            by,
+			AttachedHours:      float64(r.Attached.Round(time.Second)) / float64(time.Hour),
+			Sessions:           r.Sessions,
+			Created:            r.Created,
+			Ended:              r.Ended,
+			AvgLifetimeSeconds: int64(r.Lifetime / time.Second),
+		})
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		crashlog.Fatal("stats", version, fmt.Errorf("error encoding JSON: %v", err))
+	}
+}
+
+// formatDuration renders a duration as 45m, 3.5h or 2.1d.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%.1fh", d.Hours())
+	default:
+		return fmt.Sprintf("%.1fd", d.Hours()/24)
+	}
+}
+
+// ── sync subcommand ───────────────────────────────────────────────────────────
+
+func handleSync(args []string) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	adopt := fs.Bool("adopt", false, "Also adopt running sessions not in registry")
+	dryRun := fs.Bool("dry-run", false, "Preview changes without writing")
+	format := fs.String("format", "table", "Output format: table | json")
+	var match, exclude patternList
+	fs.Var(&match, "match", "With -adopt: only adopt sessions matching this glob or /regex/ (repeatable)")
+	fs.Var(&exclude, "exclude", "With -adopt: skip sessions matching this glob or /regex/ (repeatable)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dolly sync [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  dolly sync               # prune dead registry entries\n")
+		fmt.Fprintf(os.Stderr, "  dolly sync -adopt        # prune dead + adopt unregistered sessions\n")
+		fmt.Fprintf(os.Stderr, "  dolly sync -adopt -match 'work-*'  # adopt matching sessions only\n")
+		fmt.Fprintf(os.Stderr, "  dolly sync -dry-run      # preview changes\n")
+		fmt.Fprintf(os.Stderr, "  dolly sync -format json  # JSON output\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	// 1. Get all live tmux sessions
+	liveSessions, err := tmux.ListSessions()
+	if err != nil {
+		crashlog.Fatal("sync", version, fmt.Errorf("error listing tmux sessions: %v", err))
+	}
+	liveSet := make(map[string]bool, len(liveSessions))
+	for _, s := range liveSessions {
+		liveSet[s] = true
+	}
+	var filter registry.Filter
+	if *adopt {
+		filter = adoptionFilter("sync", match, exclude)
+	}
+
+	var removed, adopted []string
+	var pruned []registry.Entry
+	reconcile := func(reg *registry.Registry) error {
+		// 2. Compute which registry entries are dead
+		managedSet := make(map[string]bool, len(reg.Sessions))
+		var kept []registry.Entry
+		for _, entry := range reg.Sessions {
+			managedSet[entry.Name] = true
+			if liveSet[entry.Name] {
+				kept = append(kept, entry)
+			} else {
+				removed = append(removed, entry.Name)
+				pruned = append(pruned, entry)
+			}
+		}
+
+		// 3. Compute which live sessions are unmanaged (only if -adopt)
+		var newEntries []registry.Entry
+		if *adopt {
+			for _, name := range liveSessions {
+				if ok, _ := filter.Allows(name); ok && !managedSet[name] {
+					adopted = append(adopted, name)
+					if *dryRun {
+						continue
+					}
+					windows, workingDir, _ := tmux.GetSessionDetails(name)
+					now := time.Now()
+					newEntries = append(newEntries, registry.Entry{
+						Name:       name,
+						Type:       registry.TypeAttached,
+						CreatedAt:  now,
+						LastActive: now,
+						WorkingDir: workingDir,
+						Windows:    windows,
+						Terminal:   tmux.DetectShell(),
+						Structure:  snapshotStructure(name),
+						Protected:  tmux.IsProtected(name),
+					})
+				}
+			}
+		}
+
+		// 4. Build the final slice; it is saved once by registry.Update
+		reg.Sessions = append(kept, newEntries...)
+		if reg.Sessions == nil {
+			reg.Sessions = []registry.Entry{}
+		}
+		return nil
+	}
+
+	// 5. Single locked write (read-only in dry-run)
+	if *dryRun {
+		reg, lerr := registry.Load()
+		if lerr != nil {
+			crashlog.Fatal("sync", version, fmt.Errorf("error loading registry: %v", lerr))
+		}
+		reconcile(reg)
+	} else if uerr := registry.Update(reconcile); uerr != nil {
+		crashlog.Fatal("sync", version, fmt.Errorf("error updating registry: %v", uerr))
+	} else {
+		for _, e := range pruned {
+			recordEvent(history.ActionSyncRemove, e.Name, e.Type, e.ConfigFile, "not running")
+		}
+		for _, name := range adopted {
+			recordEvent(history.ActionSyncAdopt, name, registry.TypeAttached, "", "")
+		}
+	}
+
+	// 6. Output
+	if strings.ToLower(*format) == "json" {
+		printSyncJSON(removed, adopted, *dryRun)
+	} else {
+		printSyncTable(removed, adopted, *dryRun)
+	}
+}
+
+func printSyncTable(removed, adopted []string, dryRun bool) {
+	if len(removed) == 0 && len(adopted) == 0 {
+		fmt.Println("Registry is already in sync with live tmux sessions.")
+		return
+	}
 	prefix := ""
 	if dryRun {
 		prefix = "[dry-run] "
@@ -786,120 +2070,617 @@ func printSyncTable(removed, adopted []string, dryRun bool) {
 	for _, name := range removed {
 		fmt.Printf("%sWould remove: %s (dead)\n", prefix, name)
 	}
-	for _, name := range adopted {
-		fmt.Printf("%sWould adopt: %s (unmanaged)\n", prefix, name)
+	for _, name := range adopted {
+		fmt.Printf("%sWould adopt: %s (unmanaged)\n", prefix, name)
+	}
+	if dryRun {
+		fmt.Println("No changes made.")
+	} else {
+		fmt.Printf("Sync complete. Removed %d, adopted %d.\n", len(removed), len(adopted))
+	}
+}
+
+func printSyncJSON(removed, adopted []string, dryRun bool) {
+	if removed == nil {
+		removed = []string{}
+	}
+	if adopted == nil {
+		adopted = []string{}
+	}
+	out := struct {
+		DryRun  bool     `json:"dry_run"`
+		Removed []string `json:"removed"`
+		Adopted []string `json:"adopted"`
+	}{dryRun, removed, adopted}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(out)
+}
+
+// ── shortcuts subcommand ─────────────────────────────────────────────────────
+
+func handleShortcuts(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		handleShortcutsList(args)
+		return
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 3 {
+			fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts add NAME \"COMMAND\"\n")
+			os.Exit(1)
+		}
+		handleShortcutsAdd(args[1], args[2])
+	case "remove":
+		if len(args) < 2 {
+			fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts remove NAME\n")
+			os.Exit(1)
+		}
+		handleShortcutsRemove(args[1])
+	case "reset":
+		handleShortcutsReset()
+	case "sync":
+		handleShortcutsSync()
+	case "docs":
+		handleShortcutsDocs(args[1:])
+	case "check":
+		handleShortcutsCheck(args[1:])
+	case "import":
+		handleShortcutsImport(args[1:])
+	case "bundle":
+		handleShortcutsBundle(args[1:])
+	case "trust":
+		handleShortcutsTrust(args[1:], true)
+	case "untrust":
+		handleShortcutsTrust(args[1:], false)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown shortcuts action: %s\n", args[0])
+		fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts [add|remove|reset|sync|docs|check|import|bundle|trust|untrust]\n")
+		os.Exit(1)
+	}
+}
+
+// handleShortcutsList shows the merged shortcuts and the layer each one came
+// from. Without -session the project layer is found from the current
+// directory; with it, from the session's working directory and YAML.
+func handleShortcutsList(args []string) {
+	fs := flag.NewFlagSet("shortcuts", flag.ExitOnError)
+	session := fs.String("session", "", "Show the shortcuts a registered session sees, including its project and YAML layers")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts [-session NAME]\n")
+		fmt.Fprintf(os.Stderr, "       dolly shortcuts [add|remove|reset|sync|docs|check|import|bundle|trust|untrust]\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nLayers, lowest priority first: default, global (~/.dolly/shortcuts.yml),\n")
+		fmt.Fprintf(os.Stderr, "project (.dolly/shortcuts.yml above the working directory), session (YAML shortcuts:).\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	var layers shortcutLayers
+	var err error
+	if *session != "" {
+		e, ok := findEntry(*session)
+		if !ok {
+			crashlog.Exit(fmt.Errorf("session %q is not in the registry", *session))
+		}
+		layers, err = sessionShortcutLayers(e.WorkingDir, e.ConfigFile)
+	} else {
+		layers, err = sessionShortcutLayers("", "")
+	}
+	if err != nil {
+		crashlog.Fatal("shortcuts", version, err)
+	}
+
+	merged := shortcuts.Merge(layers.defaults, layers.global, layers.project, layers.session)
+	sources := shortcuts.Sources(layers.defaults, layers.global, layers.project, layers.session)
+
+	type entry struct {
+		group, name, source, command string
+	}
+	var entries []entry
+	for name, sc := range merged {
+		group := shortcuts.GroupOf(name)
+		if sources[name] == shortcuts.SourceGlobal {
+			if ug := shortcuts.UserGroupOf(layers.userGroups, name); ug != "" {
+				group = ug
+			} else if pack := shortcuts.UserGroupOf(layers.packs, name); pack != "" {
+				group = pack
+			}
+		}
+		entries = append(entries, entry{group, name, sources[name], sc.Command})
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No shortcuts configured.")
+		return
+	}
+
+	// Sort by group then name; ungrouped (non-default) entries sort last
+	sort.Slice(entries, func(i, j int) bool {
+		gi, gj := entries[i].group, entries[j].group
+		if gi != gj {
+			// empty group sorts after named groups
+			if gi == "" {
+				return false
+			}
+			if gj == "" {
+				return true
+			}
+			return gi < gj
+		}
+		return entries[i].name < entries[j].name
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tNAME\tSOURCE\tCOMMAND")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.group, e.name, e.source, e.command)
+	}
+	w.Flush()
+	if layers.projectFile != "" {
+		fmt.Printf("\nProject shortcuts: %s\n", layers.projectFile)
+	}
+}
+
+// handleShortcutsDocs renders the user's own shortcuts as a markdown
+// reference, one section per layer, in the shape of docs/shortcuts.md.
+func handleShortcutsDocs(args []string) {
+	fs := flag.NewFlagSet("shortcuts docs", flag.ExitOnError)
+	session := fs.String("session", "", "Document the shortcuts a registered session sees, including its project and YAML layers")
+	all := fs.Bool("all", false, "Include the built-in shortcuts")
+	output := fs.String("o", "", "Write to FILE instead of stdout")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts docs [-session NAME] [-all] [-o FILE]\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  dolly shortcuts docs                      # global and project shortcuts\n")
+		fmt.Fprintf(os.Stderr, "  dolly shortcuts docs -session api -all    # everything session 'api' sees\n")
+		fmt.Fprintf(os.Stderr, "  dolly shortcuts docs -o SHORTCUTS.md\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	var layers shortcutLayers
+	var err error
+	sessionTitle := "Session"
+	if *session != "" {
+		e, ok := findEntry(*session)
+		if !ok {
+			crashlog.Exit(fmt.Errorf("session %q is not in the registry", *session))
+		}
+		layers, err = sessionShortcutLayers(e.WorkingDir, e.ConfigFile)
+		sessionTitle = fmt.Sprintf("Session %s (%s)", e.Name, orDash(e.ConfigFile))
+	} else {
+		layers, err = sessionShortcutLayers("", "")
+	}
+	if err != nil {
+		crashlog.Fatal("shortcuts", version, err)
+	}
+	merged := shortcuts.Merge(layers.defaults, layers.global, layers.project, layers.session)
+	sources := shortcuts.Sources(layers.defaults, layers.global, layers.project, layers.session)
+
+	// Each section lists the shortcuts that layer wins for
+	sections := []struct {
+		source, title string
+	}{
+		{shortcuts.SourceGlobal, "Global (~/.dolly/shortcuts.yml)"},
+		{shortcuts.SourceProject, fmt.Sprintf("Project (%s)", layers.projectFile)},
+		{shortcuts.SourceSession, sessionTitle},
+	}
+	if *all {
+		sections = append(sections, struct{ source, title string }{shortcuts.SourceDefault, "Built-in"})
+	}
+
+	var b strings.Builder
+	b.WriteString("# Shortcuts\n\n")
+	b.WriteString("<!-- Generated by `dolly shortcuts docs`. Run any shortcut with -h for its usage. -->\n")
+	written := 0
+	for _, sec := range sections {
+		layer := map[string]shortcuts.Shortcut{}
+		for name, sc := range merged {
+			if sources[name] == sec.source {
+				layer[name] = sc
+			}
+		}
+		if len(layer) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n%s", sec.title, shortcuts.Markdown(layer))
+		written += len(layer)
+	}
+	if written == 0 {
+		b.WriteString("\nNo shortcuts of your own yet. Add one with `dolly shortcuts add NAME \"COMMAND\"`.\n")
+	}
+
+	if *output == "" {
+		fmt.Print(b.String())
+		return
+	}
+	if err := os.WriteFile(expandHome(*output), []byte(b.String()), 0644); err != nil {
+		crashlog.Exit(fmt.Errorf("could not write %s: %w", *output, err))
+	}
+	fmt.Printf("Wrote %d %s to %s\n", written, plural(written, "shortcut", "shortcuts"), *output)
+}
+
+// handleShortcutsCheck renders each session's shortcuts file into a scratch
+// directory and checks it with the session's shell. Without -session every
+// registered session is checked, or the current directory when there are
+// none. Exits non-zero when any shortcut would break the file.
+func handleShortcutsCheck(args []string) {
+	fs := flag.NewFlagSet("shortcuts check", flag.ExitOnError)
+	session := fs.String("session", "", "Check only this registered session")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts check [-session NAME]\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nErrors are syntax errors that stop the shortcuts file from loading in a pane.\n")
+		fmt.Fprintf(os.Stderr, "Warnings are names that shadow a builtin or a command on PATH, and unquoted $1.\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
 	}
-	if dryRun {
-		fmt.Println("No changes made.")
+
+	type target struct {
+		name, terminal, workingDir, configFile string
+	}
+	var targets []target
+	if *session != "" {
+		e, ok := findEntry(*session)
+		if !ok {
+			crashlog.Exit(fmt.Errorf("session %q is not in the registry", *session))
+		}
+		targets = append(targets, target{e.Name, e.Terminal, e.WorkingDir, e.ConfigFile})
 	} else {
-		fmt.Printf("Sync complete. Removed %d, adopted %d.\n", len(removed), len(adopted))
+		reg, err := registry.Load()
+		if err != nil {
+			crashlog.Fatal("shortcuts", version, fmt.Errorf("error loading registry: %v", err))
+		}
+		for _, s := range reg.Sessions {
+			targets = append(targets, target{s.Name, s.Terminal, s.WorkingDir, s.ConfigFile})
+		}
+		if len(targets) == 0 {
+			targets = append(targets, target{name: "current directory"})
+		}
 	}
-}
 
-func printSyncJSON(removed, adopted []string, dryRun bool) {
-	if removed == nil {
-		removed = []string{}
+	errCount, warnCount := 0, 0
+	for i, t := range targets {
+		if t.terminal == "" {
+			t.terminal = tmux.DetectShell()
+		}
+		l, err := sessionShortcutLayers(t.workingDir, t.configFile)
+		if err != nil {
+			crashlog.Fatal("shortcuts", version, err)
+		}
+		merged := shortcuts.Merge(l.defaults, l.global, l.project, l.session)
+		problems, err := shortcuts.Check("check", t.terminal, merged)
+		if err != nil {
+			crashlog.Fatal("shortcuts", version, err)
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s (%s, %d %s)\n", t.name, t.terminal, len(merged), plural(len(merged), "shortcut", "shortcuts"))
+		if len(problems) == 0 {
+			fmt.Println("  ok")
+			continue
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, p := range problems {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", p.Severity, orDash(p.Shortcut), p.Message)
+			if p.Severity == shortcuts.SeverityError {
+				errCount++
+			} else {
+				warnCount++
+			}
+		}
+		w.Flush()
 	}
-	if adopted == nil {
-		adopted = []string{}
+
+	fmt.Printf("\n%d %s, %d %s\n", errCount, plural(errCount, "error", "errors"), warnCount, plural(warnCount, "warning", "warnings"))
+	if errCount > 0 {
+		os.Exit(1)
 	}
-	out := struct {
-		DryRun  bool     `json:"dry_run"`
-		Removed []string `json:"removed"`
-		Adopted []string `json:"adopted"`
-	}{dryRun, removed, adopted}
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	enc.Encode(out)
 }
 
-// ── shortcuts subcommand ─────────────────────────────────────────────────────
+// handleShortcutsImport turns the aliases and simple functions of a shell rc
+// file, or of `alias` output on stdin, into global shortcuts. Names that are
+// invalid or already global are skipped; the rest are previewed and added
+// after confirmation.
+func handleShortcutsImport(args []string) {
+	fs := flag.NewFlagSet("shortcuts import", flag.ExitOnError)
+	from := fs.String("from", "", "Read FILE, or stdin with -, instead of your shell's rc file")
+	dryRun := fs.Bool("dry-run", false, "Show what would be imported without changing anything")
+	yes := fs.Bool("yes", false, "Import without asking")
 
-func handleShortcuts(args []string) {
-	if len(args) == 0 {
-		handleShortcutsList()
-		return
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts import [-from FILE|-] [-dry-run] [-yes]\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  dolly shortcuts import -dry-run             # preview from ~/.bashrc, ~/.zshrc or config.fish\n")
+		fmt.Fprintf(os.Stderr, "  dolly shortcuts import -from ~/.aliases\n")
+		fmt.Fprintf(os.Stderr, "  alias | dolly shortcuts import -from - -yes  # the aliases of the current shell\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
 	}
 
-	switch args[0] {
-	case "add":
-		if len(args) < 3 {
-			fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts add NAME \"COMMAND\"\n")
-			os.Exit(1)
+	source := *from
+	if source == "" {
+		shell := tmux.DetectShell()
+		source = map[string]string{
+			"bash": "~/.bashrc",
+			"zsh":  "~/.zshrc",
+			"fish": "~/.config/fish/config.fish",
+			"sh":   "~/.profile",
+			"dash": "~/.profile",
+		}[shell]
+		if source == "" {
+			// config.nu is not alias syntax dolly can read
+			crashlog.Exit(fmt.Errorf("no rc file dolly can read for %s; pass -from FILE, or -from - with `alias` output", shell))
 		}
-		handleShortcutsAdd(args[1], args[2])
-	case "remove":
-		if len(args) < 2 {
-			fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts remove NAME\n")
-			os.Exit(1)
+		fmt.Printf("Reading %s (the rc file for %s; use -from to pick another)\n\n", source, shell)
+	}
+	var found []shortcuts.Candidate
+	var err error
+	if source == "-" {
+		if !*yes && !*dryRun {
+			crashlog.Exit(fmt.Errorf("stdin is used for the input; pass -yes or -dry-run with -from -"))
 		}
-		handleShortcutsRemove(args[1])
-	case "reset":
-		handleShortcutsReset()
-	case "sync":
-		handleShortcutsSync()
-	default:
-		fmt.Fprintf(os.Stderr, "Unknown shortcuts action: %s\n", args[0])
-		fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts [add|remove|reset|sync]\n")
-		os.Exit(1)
+		found, err = shortcuts.ParseRC(os.Stdin)
+		source = "stdin"
+	} else {
+		f, openErr := os.Open(expandHome(source))
+		if openErr != nil {
+			crashlog.Exit(fmt.Errorf("could not read %s: %w", source, openErr))
+		}
+		found, err = shortcuts.ParseRC(f)
+		f.Close()
+	}
+	if err != nil {
+		crashlog.Exit(fmt.Errorf("could not read %s: %w", source, err))
 	}
-}
 
-func handleShortcutsList() {
 	global, err := shortcuts.LoadGlobal()
 	if err != nil {
-		crashlog.Fatal("shortcuts", version, fmt.Errorf("error loading global shortcuts: %v", err))
+		crashlog.Fatal("shortcuts", version, err)
 	}
-
-	// Build combined list with source and group tracking
-	type entry struct {
-		group, name, source, command string
+	var add, skipped []shortcuts.Candidate
+	for _, c := range found {
+		if c.Skip == "" {
+			if existing, ok := global[c.Name]; ok {
+				c.Skip = "already a global shortcut"
+				if existing.Command != c.Command {
+					c.Skip += " with a different command"
+				}
+			} else if _, builtin := shortcuts.Defaults[c.Name]; builtin && c.Warning == "" {
+				c.Warning = "replaces the built-in shortcut"
+			}
+		}
+		if c.Skip != "" {
+			skipped = append(skipped, c)
+		} else {
+			add = append(add, c)
+		}
 	}
-	var entries []entry
 
-	// Defaults first
-	for name, cmd := range shortcuts.DefaultShortcuts {
-		source := "default"
-		if gcmd, ok := global[name]; ok {
-			cmd = gcmd
-			source = "global"
+	if len(add) > 0 {
+		fmt.Printf("From %s:\n", source)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  NAME\tKIND\tCOMMAND")
+		for _, c := range add {
+			command, more, _ := strings.Cut(c.Command, "\n")
+			if more != "" {
+				command += " ..."
+			}
+			if c.Warning != "" {
+				command += "  (" + c.Warning + ")"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", c.Name, c.Kind, command)
 		}
-		entries = append(entries, entry{shortcuts.GroupOf(name), name, source, cmd})
+		w.Flush()
 	}
-	// Global-only (not overriding a default)
-	for name, cmd := range global {
-		if _, isDefault := shortcuts.DefaultShortcuts[name]; !isDefault {
-			entries = append(entries, entry{"", name, "global", cmd})
+	if len(skipped) > 0 {
+		if len(add) > 0 {
+			fmt.Println()
+		}
+		fmt.Println("Skipped:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, c := range skipped {
+			fmt.Fprintf(w, "  line %d\t%s\t%s\n", c.Line, orDash(c.Name), c.Skip)
 		}
+		w.Flush()
 	}
-
-	if len(entries) == 0 {
-		fmt.Println("No shortcuts configured.")
+	if len(add) == 0 {
+		fmt.Printf("No aliases or functions to import from %s.\n", source)
+		return
+	}
+	if *dryRun {
+		fmt.Printf("\nDry run: would add %d %s.\n", len(add), plural(len(add), "shortcut", "shortcuts"))
 		return
 	}
+	if !*yes {
+		fmt.Println()
+		ok, err := prompt.NewReader().Confirm(fmt.Sprintf("Add %d %s to ~/.dolly/shortcuts.yml?", len(add), plural(len(add), "shortcut", "shortcuts")))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if !ok {
+			fmt.Println("Aborted.")
+			return
+		}
+	}
 
-	// Sort by group then name; ungrouped (global-only) entries sort last
-	sort.Slice(entries, func(i, j int) bool {
-		gi, gj := entries[i].group, entries[j].group
-		if gi != gj {
-			// empty group sorts after named groups
-			if gi == "" {
-				return false
-			}
-			if gj == "" {
-				return true
+	for _, c := range add {
+		global[c.Name] = shortcuts.Shortcut{Command: c.Command}
+	}
+	if err := shortcuts.SaveGlobal(global); err != nil {
+		crashlog.Fatal("shortcuts", version, err)
+	}
+	recordEvent(history.ActionShortcuts, "", "", "", fmt.Sprintf("import %d from %s", len(add), source))
+	fmt.Printf("Imported %d %s into global shortcuts.\n", len(add), plural(len(add), "shortcut", "shortcuts"))
+	printShortcutsReach(rewriteShortcutFiles(false))
+}
+
+// handleShortcutsBundle dispatches `dolly shortcuts bundle export|list`.
+// A bundle is a shortcut pack: a YAML file that other people load by adding
+// its directory to shortcut_sources: in their ~/.dolly/shortcuts.yml.
+func handleShortcutsBundle(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts bundle [export NAME|list]\n")
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "export":
+		handleShortcutsBundleExport(args[1:])
+	case "list":
+		handleShortcutsBundleList()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown bundle action: %s\n", args[0])
+		fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts bundle [export NAME|list]\n")
+		os.Exit(1)
+	}
+}
+
+// handleShortcutsBundleExport writes global shortcuts as a pack named NAME.
+func handleShortcutsBundleExport(args []string) {
+	fs := flag.NewFlagSet("shortcuts bundle export", flag.ExitOnError)
+	group := fs.String("group", "", "Export this group from ~/.dolly/shortcuts.yml instead of the ungrouped shortcuts")
+	output := fs.String("o", "", "Write to FILE (default NAME.yml)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dolly shortcuts bundle export NAME [-group GROUP] [-o FILE]\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  dolly shortcuts bundle export ops -group ops -o ~/src/platform-shortcuts/ops.yml\n")
+		fmt.Fprintf(os.Stderr, "\nShortcuts in the pack are defined as NAME_SHORTCUT, e.g. ops_deploy.\n")
+	}
+	// NAME comes first, like "dolly shortcuts add NAME"
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fs.Usage()
+		os.Exit(1)
+	}
+	name := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		os.Exit(1)
+	}
+
+	var sc map[string]shortcuts.Shortcut
+	var err error
+	if *group != "" {
+		var groups map[string]map[string]shortcuts.Shortcut
+		if groups, err = shortcuts.LoadGroups(); err == nil {
+			var ok bool
+			if sc, ok = groups[*group]; !ok {
+				crashlog.Exit(fmt.Errorf("no group %q in ~/.dolly/shortcuts.yml", *group))
 			}
-			return gi < gj
 		}
-		return entries[i].name < entries[j].name
-	})
+	} else {
+		sc, err = shortcuts.LoadGlobal()
+	}
+	if err != nil {
+		crashlog.Fatal("shortcuts", version, err)
+	}
+	if len(sc) == 0 && *group != "" {
+		crashlog.Exit(fmt.Errorf("nothing to export: group %q is empty", *group))
+	}
+	if len(sc) == 0 {
+		crashlog.Exit(fmt.Errorf("nothing to export: no global shortcuts yet"))
+	}
+
+	path := *output
+	if path == "" {
+		path = name + ".yml"
+	}
+	if err := shortcuts.ExportPack(expandHome(path), name, sc); err != nil {
+		crashlog.Exit(err)
+	}
+	fmt.Printf("Wrote %d %s to %s as pack '%s'.\n", len(sc), plural(len(sc), "shortcut", "shortcuts"), path, name)
+	fmt.Printf("To use it, add its directory to shortcut_sources: in ~/.dolly/shortcuts.yml.\n")
+}
+
+// handleShortcutsBundleList shows the packs loaded from shortcut_sources:.
+func handleShortcutsBundleList() {
+	dirs, err := shortcuts.LoadSources()
+	if err != nil {
+		crashlog.Fatal("shortcuts", version, err)
+	}
+	if len(dirs) == 0 {
+		fmt.Println("No shortcut_sources: in ~/.dolly/shortcuts.yml.")
+		return
+	}
+	packs, err := shortcuts.ReadPacks()
+	if err != nil {
+		tmux.WarnShortcutPacks(err)
+	}
+	if len(packs) == 0 {
+		fmt.Printf("No packs found in %s.\n", strings.Join(dirs, ", "))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tSHORTCUTS\tFILE")
+	for _, p := range packs {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", p.Namespace, len(p.Shortcuts), p.Path)
+	}
+	w.Flush()
+}
+
+// handleShortcutsTrust records (or forgets) the project shortcuts file found
+// from DIR, the current directory by default. Project files are only loaded
+// once trusted, and trust lapses when the file changes.
+func handleShortcutsTrust(args []string, trust bool) {
+	dir := "."
+	if len(args) > 0 {
+		dir = expandHome(args[0])
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		crashlog.Exit(err)
+	}
+
+	if !trust {
+		path, err := shortcuts.Untrust(abs)
+		if err != nil {
+			crashlog.Fatal("shortcuts", version, err)
+		}
+		if path == "" {
+			fmt.Printf("No trusted project shortcuts at or above %s.\n", abs)
+			return
+		}
+		fmt.Printf("Project shortcuts in %s are no longer loaded.\n", path)
+		printShortcutsReach(rewriteShortcutFiles(false))
+		return
+	}
 
+	path, sc, err := shortcuts.Trust(abs)
+	if err != nil {
+		crashlog.Exit(err)
+	}
+	fmt.Printf("Trusted %d project %s from %s:\n", len(sc), plural(len(sc), "shortcut", "shortcuts"), path)
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "GROUP\tNAME\tSOURCE\tCOMMAND")
-	for _, e := range entries {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.group, e.name, e.source, e.command)
+	names := make([]string, 0, len(sc))
+	for name := range sc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", name, sc[name].Command)
 	}
 	w.Flush()
+	fmt.Println("The file must be trusted again after it changes.")
+	recordEvent(history.ActionShortcuts, "", "", "", "trust "+path)
+	printShortcutsReach(rewriteShortcutFiles(false))
 }
 
 func handleShortcutsAdd(name, command string) {
@@ -915,57 +2696,155 @@ func handleShortcutsAdd(name, command string) {
 	if _, err := shortcuts.AddGlobal(name, command); err != nil {
 		crashlog.Fatal("shortcuts", version, err)
 	}
+	recordEvent(history.ActionShortcuts, "", "", "", "add "+name)
 	fmt.Printf("Shortcut '%s' added to global shortcuts.\n", name)
+	if global, err := shortcuts.LoadGlobal(); err == nil {
+		if sc := global[name]; sc.Description != "" || sc.Example != "" || len(sc.Args) > 0 {
+			fmt.Printf("Kept its description, example and args; edit ~/.dolly/shortcuts.yml if they no longer fit the new command.\n")
+		}
+	}
+	printShortcutsReach(rewriteShortcutFiles(false))
 }
 
 func handleShortcutsRemove(name string) {
-	if err := shortcuts.RemoveGlobal(name); err != nil {
+	group, err := shortcuts.RemoveGlobal(name)
+	if err != nil {
 		crashlog.Fatal("shortcuts", version, err)
 	}
-	fmt.Printf("Shortcut '%s' removed from global shortcuts.\n", name)
+	recordEvent(history.ActionShortcuts, "", "", "", "remove "+name)
+	if group != "" {
+		fmt.Printf("Shortcut '%s' removed from group '%s' in global shortcuts.\n", name, group)
+	} else {
+		fmt.Printf("Shortcut '%s' removed from global shortcuts.\n", name)
+	}
+	printShortcutsReach(rewriteShortcutFiles(false))
 }
 
 func handleShortcutsReset() {
 	if err := shortcuts.ResetGlobal(); err != nil {
 		crashlog.Fatal("shortcuts", version, err)
 	}
+	recordEvent(history.ActionShortcuts, "", "", "", "reset")
 	fmt.Println("Global shortcuts reset. Built-in defaults will still apply.")
+	printShortcutsReach(rewriteShortcutFiles(false))
 }
 
 func handleShortcutsSync() {
-	reg, err := registry.Load()
+	synced := rewriteShortcutFiles(true)
+	if synced == 0 {
+		fmt.Println("No live sessions to sync.")
+		return
+	}
+	fmt.Printf("\n%d %s updated. Panes reload their shortcuts at the next prompt.\n",
+		synced, plural(synced, "session", "sessions"))
+	fmt.Printf("Panes started by an older dolly need one manual reload:\n    source $DOLLY_SHORTCUTS_FILE\n")
+}
+
+// shortcutLayers are the per-session inputs to shortcuts.Merge.
+type shortcutLayers struct {
+	defaults    map[string]shortcuts.Shortcut // nil when the YAML sets default_shortcuts: false
+	global      map[string]shortcuts.Shortcut // ungrouped plus selected user groups and packs
+	project     map[string]shortcuts.Shortcut
+	projectFile string
+	session     map[string]shortcuts.Shortcut // shortcuts: from the session's YAML
+	userGroups  map[string]map[string]shortcuts.Shortcut
+	packs       map[string]map[string]shortcuts.Shortcut // by namespace, from shortcut_sources:
+}
+
+// sessionShortcutLayers resolves the layers a session sees, the same way
+// tmux.CreateTmuxSession does: project shortcuts from its working directory
+// and, for YAML sessions, its own shortcuts, default_shortcuts setting and
+// shortcut group selection. Only a broken global shortcuts file is an error.
+func sessionShortcutLayers(workingDir, configFile string) (shortcutLayers, error) {
+	var l shortcutLayers
+	var filter shortcuts.GroupFilter
+	useDefaults := true
+	if configFile != "" {
+		if cfg, err := config.LoadConfig(configFile); err == nil {
+			l.session = cfg.Shortcuts
+			useDefaults = cfg.DefaultShortcuts == nil || *cfg.DefaultShortcuts
+			filter = shortcuts.GroupFilter{Include: cfg.ShortcutGroups, Exclude: cfg.ExcludeShortcutGroups}
+			if workingDir == "" {
+				workingDir = cfg.WorkingDirectory
+			}
+		}
+	}
+
+	var err error
+	if l.userGroups, err = shortcuts.LoadGroups(); err != nil {
+		return l, fmt.Errorf("error loading global shortcuts: %v", err)
+	}
+	l.packs = shortcutPacks()
+	if l.global, err = shortcuts.LoadGlobalLayer(filter, l.packs); err != nil {
+		return l, fmt.Errorf("error loading global shortcuts: %v", err)
+	}
+	for _, g := range filter.Unknown(l.userGroups, l.packs) {
+		fmt.Fprintf(os.Stderr, "Warning: %s: unknown shortcut group %q\n", configFile, g)
+	}
+	if useDefaults {
+		l.defaults = shortcuts.SelectDefaults(filter)
+	}
+
+	l.project, l.projectFile, err = shortcuts.LoadProject(workingDir)
 	if err != nil {
-		crashlog.Fatal("shortcuts", version, fmt.Errorf("error loading registry: %v", err))
+		fmt.Fprintf(os.Stderr, "Warning: ignoring project shortcuts: %v\n", err)
 	}
+	return l, nil
+}
 
-	global, err := shortcuts.LoadGlobal()
+// shortcutPacks loads the shortcut packs once per dolly invocation, so a
+// command that resolves the layers of every live session reads the sources
+// and warns about a broken pack only once.
+var shortcutPacks = sync.OnceValue(func() map[string]map[string]shortcuts.Shortcut {
+	packs, err := shortcuts.LoadPacks()
 	if err != nil {
-		crashlog.Fatal("shortcuts", version, fmt.Errorf("error loading global shortcuts: %v", err))
+		tmux.WarnShortcutPacks(err)
+	}
+	return packs
+})
+
+// printShortcutsReach tells the user which running sessions will see a
+// global shortcut change.
+func printShortcutsReach(synced int) {
+	if synced > 0 {
+		fmt.Printf("Updated %d live %s; panes pick up the change at their next prompt.\n",
+			synced, plural(synced, "session", "sessions"))
 	}
+}
 
-	// Merge defaults + globals — same result for every session.
-	merged := shortcuts.Merge(shortcuts.DefaultShortcuts, global, nil)
+// rewriteShortcutFiles rewrites the shortcuts file of every live registered
+// session. The prompt hook inside each file re-sources it, so running panes
+// see the change without any action. With report set, each session is
+// printed and logged to history. Returns how many sessions were updated.
+func rewriteShortcutFiles(report bool) int {
+	reg, err := registry.Load()
+	if err != nil {
+		crashlog.Fatal("shortcuts", version, fmt.Errorf("error loading registry: %v", err))
+	}
 
+	live := tmux.LiveSessions()
 	synced := 0
 	for _, s := range reg.Sessions {
-		if !tmux.IsSessionAlive(s.Name) {
+		if !live[s.Name] {
 			continue
 		}
+		l, err := sessionShortcutLayers(s.WorkingDir, s.ConfigFile)
+		if err != nil {
+			crashlog.Fatal("shortcuts", version, err)
+		}
+		merged := shortcuts.Merge(l.defaults, l.global, l.project, l.session)
 		path, err := shortcuts.WriteShellFile(s.Name, s.Terminal, merged)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "  error syncing '%s': %v\n", s.Name, err)
 			continue
 		}
-		fmt.Printf("  synced  %s  →  %s\n", s.Name, path)
+		if report {
+			fmt.Printf("  synced  %s  →  %s\n", s.Name, path)
+			recordEvent(history.ActionShortcuts, s.Name, s.Type, s.ConfigFile, "sync")
+		}
 		synced++
 	}
-
-	if synced == 0 {
-		fmt.Println("No live sessions to sync.")
-		return
-	}
-	fmt.Printf("\n%d %s updated. Run this in each pane to apply:\n    source $DOLLY_SHORTCUTS_FILE\n",
-		synced, plural(synced, "session", "sessions"))
+	return synced
 }
 
 // ── report subcommand ─────────────────────────────────────────────────────────
@@ -1150,6 +3029,80 @@ func handleReport(args []string) {
 
 // ── helpers ───────────────────────────────────────────────────────────────────
 
+// patternList is a repeatable string flag (-match a -match b).
+type patternList []string
+
+func (p *patternList) String() string { return strings.Join(*p, ",") }
+
+func (p *patternList) Set(v string) error {
+	*p = append(*p, v)
+	return nil
+}
+
+// recordEvent appends to the history log. History is advisory, so a failed
+// write only warns.
+func recordEvent(action history.Action, name string, typ registry.SessionType, configFile, detail string) {
+	err := history.Record(history.Event{
+		Action:     action,
+		Session:    name,
+		Type:       string(typ),
+		ConfigFile: configFile,
+		Detail:     detail,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// recordRemoval logs an event for a session that is about to leave the
+// registry, copying its type and config file from the entry. Call it before
+// RemoveEntry.
+func recordRemoval(action history.Action, name string) {
+	e, _ := findEntry(name)
+	recordEvent(action, name, e.Type, e.ConfigFile, "")
+}
+
+// findEntry looks up a registry entry by name.
+func findEntry(name string) (registry.Entry, bool) {
+	if reg, err := registry.Load(); err == nil {
+		for _, e := range reg.Sessions {
+			if e.Name == name {
+				return e, true
+			}
+		}
+	}
+	return registry.Entry{}, false
+}
+
+// parseInterspersed parses args with fs like fs.Parse, but keeps going past
+// positional arguments, so flags are accepted before and after them. It
+// returns the positional arguments in order; everything after "--" is
+// positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
 func plural(n int, singular, pluralStr string) string {
 	if n == 1 {
 		return singular
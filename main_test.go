@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		args       []string
+		terminate  bool
+		force      bool
+		positional []string
+	}{
+		{[]string{"-t", "-force", "proj.yml"}, true, true, []string{"proj.yml"}},
+		{[]string{"-t", "proj.yml", "-force"}, true, true, []string{"proj.yml"}},
+		{[]string{"proj.yml", "-force", "-t"}, true, true, []string{"proj.yml"}},
+		{[]string{"proj.yml"}, false, false, []string{"proj.yml"}},
+		{[]string{"a.yml", "-t", "b.yml"}, true, false, []string{"a.yml", "b.yml"}},
+		{[]string{"-t", "--", "-force"}, true, false, []string{"-force"}},
+		{nil, false, false, nil},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("dolly", flag.ContinueOnError)
+		terminate := fs.Bool("t", false, "")
+		force := fs.Bool("force", false, "")
+		got, err := parseInterspersed(fs, tt.args)
+		if err != nil {
+			t.Errorf("%q: %v", tt.args, err)
+			continue
+		}
+		if *terminate != tt.terminate || *force != tt.force || !reflect.DeepEqual(got, tt.positional) {
+			t.Errorf("%q: got t=%v force=%v args=%q, want t=%v force=%v args=%q",
+				tt.args, *terminate, *force, got, tt.terminate, tt.force, tt.positional)
+		}
+	}
+
+	fs := flag.NewFlagSet("dolly", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if _, err := parseInterspersed(fs, []string{"proj.yml", "-nope"}); err == nil {
+		t.Error("expected an error for an unknown trailing flag")
+	}
+}
@@ -56,6 +56,21 @@ func (r *Reader) ConfirmSaveConfig() (bool, error) {
 	return false, nil
 }
 
+// Confirm asks a yes/no question; anything but y/yes (including EOF) is no.
+func (r *Reader) Confirm(question string) (bool, error) {
+	fmt.Printf("%s [y/N]: ", question)
+	if r.scanner.Scan() {
+		answer := strings.TrimSpace(strings.ToLower(r.scanner.Text()))
+		return answer == "y" || answer == "yes", nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return false, nil
+}
+
 // GetConfigFilePath prompts for config file path
 func (r *Reader) GetConfigFilePath(defaultPath string) (string, error) {
 	fmt.Printf("Enter config file path [%s]: ", defaultPath)
@@ -0,0 +1,151 @@
+package registry
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"tmux-manager/internal/dolly"
+)
+
+// Filter decides which live tmux sessions bulk adoption (attach -all,
+// attach -match, sync -adopt) may pick up.
+type Filter struct {
+	Match   []string // adopt only names matching at least one pattern; all when empty
+	Exclude []string // never adopt names matching any of these
+	Ignore  []string // persistent ignore list from ~/.dolly/attach-ignore
+}
+
+// ignoreListPath returns the absolute path to ~/.dolly/attach-ignore.
+func ignoreListPath() (string, error) {
+	dir, err := dolly.DataDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine dolly data directory: %w", err)
+	}
+	return filepath.Join(dir, "attach-ignore"), nil
+}
+
+// MatchPattern reports whether name matches pattern. Patterns wrapped in
+// slashes (/^work-\d+$/) are regular expressions; anything else is a glob
+// (work-*, scratch-?).
+func MatchPattern(pattern, name string) (bool, error) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return false, fmt.Errorf("invalid regex pattern %q: %w", pattern, err)
+		}
+		return re.MatchString(name), nil
+	}
+	ok, err := path.Match(pattern, name)
+	if err != nil {
+		return false, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+	}
+	return ok, nil
+}
+
+// Validate checks every pattern in the filter so bad input is reported once
+// up front instead of silently matching nothing.
+func (f Filter) Validate() error {
+	for _, list := range [][]string{f.Match, f.Exclude, f.Ignore} {
+		for _, p := range list {
+			if _, err := MatchPattern(p, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Allows reports whether a session may be adopted. When it may not, reason
+// names the pattern that rejected it.
+func (f Filter) Allows(name string) (ok bool, reason string) {
+	if len(f.Match) > 0 {
+		matched := false
+		for _, p := range f.Match {
+			if m, _ := MatchPattern(p, name); m {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, "does not match -match"
+		}
+	}
+	for _, p := range f.Exclude {
+		if m, _ := MatchPattern(p, name); m {
+			return false, fmt.Sprintf("excluded by %q", p)
+		}
+	}
+	for _, p := range f.Ignore {
+		if m, _ := MatchPattern(p, name); m {
+			return false, fmt.Sprintf("ignored by %q", p)
+		}
+	}
+	return true, ""
+}
+
+// LoadIgnoreList reads ~/.dolly/attach-ignore: one pattern per line, blank
+// lines and # comments skipped. Returns an empty list when the file is absent.
+func LoadIgnoreList() ([]string, error) {
+	p, err := ignoreListPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read ignore list: %w", err)
+	}
+	defer f.Close()
+
+	patterns := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read ignore list: %w", err)
+	}
+	return patterns, nil
+}
+
+// AddIgnorePattern appends a pattern to ~/.dolly/attach-ignore. Adding a
+// pattern that is already present is a no-op.
+func AddIgnorePattern(pattern string) error {
+	if _, err := MatchPattern(pattern, ""); err != nil {
+		return err
+	}
+	existing, err := LoadIgnoreList()
+	if err != nil {
+		return err
+	}
+	for _, p := range existing {
+		if p == pattern {
+			return nil
+		}
+	}
+
+	p, err := ignoreListPath()
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open ignore list: %w", err)
+	}
+	if _, err := fmt.Fprintln(f, pattern); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write ignore list: %w", err)
+	}
+	return f.Close()
+}
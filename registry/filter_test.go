@@ -0,0 +1,93 @@
+package registry
+
+import "testing"
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"work-*", "work-api", true},
+		{"work-*", "homework", false},
+		{"scratch?", "scratch1", true},
+		{`/^ide-\d+$/`, "ide-42", true},
+		{`/^ide-\d+$/`, "ide-x", false},
+		{"/api/", "work-api-2", true}, // regexes are unanchored unless anchored explicitly
+	}
+	for _, tt := range tests {
+		got, err := MatchPattern(tt.pattern, tt.name)
+		if err != nil {
+			t.Fatalf("MatchPattern(%q, %q): %v", tt.pattern, tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("MatchPattern(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	if err := (Filter{Match: []string{"/([/"}}).Validate(); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	if err := (Filter{Exclude: []string{"[a-"}}).Validate(); err == nil {
+		t.Error("expected error for invalid glob")
+	}
+	if err := (Filter{Match: []string{"work-*"}, Ignore: []string{"/tmp/"}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFilterAllows(t *testing.T) {
+	f := Filter{
+		Match:   []string{"work-*", "api"},
+		Exclude: []string{"work-old*"},
+		Ignore:  []string{"*-scratch"},
+	}
+	tests := map[string]bool{
+		"work-api":     true,
+		"api":          true,
+		"personal":     false, // no -match hit
+		"work-old-1":   false, // excluded
+		"work-scratch": false, // ignored
+	}
+	for name, want := range tests {
+		if got, _ := f.Allows(name); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	// Empty filter allows everything
+	if ok, _ := (Filter{}).Allows("anything"); !ok {
+		t.Error("empty filter should allow every session")
+	}
+}
+
+func TestIgnoreList(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	list, err := LoadIgnoreList()
+	if err != nil {
+		t.Fatalf("LoadIgnoreList on missing file: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("expected empty ignore list, got %v", list)
+	}
+
+	if err := AddIgnorePattern("scratch-*"); err != nil {
+		t.Fatalf("AddIgnorePattern: %v", err)
+	}
+	if err := AddIgnorePattern("scratch-*"); err != nil { // duplicate is a no-op
+		t.Fatalf("AddIgnorePattern duplicate: %v", err)
+	}
+	if err := AddIgnorePattern("/([/"); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+
+	list, err = LoadIgnoreList()
+	if err != nil {
+		t.Fatalf("LoadIgnoreList: %v", err)
+	}
+	if len(list) != 1 || list[0] != "scratch-*" {
+		t.Fatalf("expected [scratch-*], got %v", list)
+	}
+}
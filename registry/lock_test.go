@@ -0,0 +1,129 @@
+//go:build unix
+
+package registry
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestHelperRegistryWriter is not a real test: it is the body of the writer
+// processes spawned by TestConcurrentWriters_NoLostEntries.
+func TestHelperRegistryWriter(t *testing.T) {
+	prefix := os.Getenv("DOLLY_TEST_WRITER")
+	if prefix == "" {
+		t.Skip("helper process only")
+	}
+	n, _ := strconv.Atoi(os.Getenv("DOLLY_TEST_WRITES"))
+	for i := 0; i < n; i++ {
+		if err := AddEntry(makeEntry(fmt.Sprintf("%s-%d", prefix, i), TypeThrowaway, 0, true)); err != nil {
+			t.Fatalf("AddEntry: %v", err)
+		}
+	}
+}
+
+// ─── Concurrency: parallel writers never lose entries ────────────────────────
+
+func TestConcurrentWriters_NoLostEntries(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	const writers, writesEach = 12, 8
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestHelperRegistryWriter$")
+			cmd.Env = append(os.Environ(),
+				fmt.Sprintf("DOLLY_TEST_WRITER=writer%d", w),
+				fmt.Sprintf("DOLLY_TEST_WRITES=%d", writesEach),
+			)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				errs <- fmt.Errorf("writer %d: %v\n%s", w, err, out)
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	reg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := writers * writesEach; len(reg.Sessions) != want {
+		t.Fatalf("expected %d entries, got %d — concurrent updates were lost", want, len(reg.Sessions))
+	}
+}
+
+// ─── Load: repairs wait for the lock, plain reads do not ─────────────────────
+
+func TestLoad_RepairWaitsForLock(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	legacy := `{"sessions":[{"name":"old","type":"yaml","created_at":"2024-01-01T00:00:00Z","last_active":"2024-01-01T00:00:00Z","working_dir":"/tmp","windows":1,"terminal":"zsh"}]}`
+	path := writeRawRegistry(t, legacy)
+
+	unlock, err := lockRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := Load()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		unlock()
+		t.Fatalf("Load repaired the registry without the lock (err %v)", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if data, _ := os.ReadFile(path); string(data) != legacy {
+		t.Fatal("registry rewritten while another process held the lock")
+	}
+
+	unlock()
+	if err := <-done; err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) == legacy {
+		t.Error("registry was not upgraded once the lock was free")
+	}
+}
+
+func TestLoad_CurrentVersionDoesNotLock(t *testing.T) {
+	defer setupTestRegistry(t)()
+	if err := AddEntry(makeEntry("s", TypeYAML, 0, true)); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := lockRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	done := make(chan error, 1)
+	go func() {
+		_, err := Load()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Load of a current registry blocked on the lock")
+	}
+}
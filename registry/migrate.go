@@ -0,0 +1,155 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CurrentVersion is the registry schema version written by this build.
+// Files without a version field are version 1.
+const CurrentVersion = 2
+
+// migration upgrades the raw session objects of a registry document from
+// version `from` to version from+1. Sessions are decoded into generic maps
+// so a migration can rename, default or drop fields before the typed decode.
+type migration struct {
+	from        int
+	description string
+	apply       func(sessions []map[string]interface{}) []map[string]interface{}
+}
+
+// migrations is the ordered upgrade path. To change the schema, bump
+// CurrentVersion and append a step here; never edit a released step.
+var migrations = []migration{
+	{
+		from:        1,
+		description: "add schema version; drop nameless entries and default a missing type to attached",
+		apply: func(sessions []map[string]interface{}) []map[string]interface{} {
+			out := sessions[:0]
+			for _, s := range sessions {
+				if name, _ := s["name"].(string); name == "" {
+					continue
+				}
+				if t, _ := s["type"].(string); t == "" {
+					s["type"] = string(TypeAttached)
+				}
+				out = append(out, s)
+			}
+			return out
+		},
+	},
+}
+
+// migrate runs every step from version up to CurrentVersion.
+func migrate(version int, sessions []map[string]interface{}) []map[string]interface{} {
+	for _, m := range migrations {
+		if m.from >= version && m.from < CurrentVersion {
+			sessions = m.apply(sessions)
+		}
+	}
+	return sessions
+}
+
+// decodeResult is what decode salvaged from a registry file.
+type decodeResult struct {
+	reg         *Registry
+	fromVersion int               // schema version found on disk
+	quarantined []json.RawMessage // entries that could not be decoded
+	unreadable  bool              // the document itself was not a JSON object
+}
+
+// needsRepair reports whether the document must be rewritten: it was not
+// JSON, had undecodable entries or used an older schema version.
+func (r *decodeResult) needsRepair() bool {
+	return r.unreadable || len(r.quarantined) > 0 || r.fromVersion < CurrentVersion
+}
+
+// decode parses a registry document of any known version. Entries that fail
+// to decode are collected for quarantine instead of failing the whole load.
+func decode(data []byte) (*decodeResult, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return &decodeResult{reg: &Registry{Version: CurrentVersion, Sessions: []Entry{}}, unreadable: true}, nil
+	}
+
+	res := &decodeResult{fromVersion: 1}
+	if raw, ok := doc["version"]; ok {
+		if err := json.Unmarshal(raw, &res.fromVersion); err != nil {
+			res.fromVersion = 1
+		}
+	}
+	if res.fromVersion < 1 {
+		res.fromVersion = 1
+	}
+	if res.fromVersion > CurrentVersion {
+		return nil, fmt.Errorf("registry schema version %d is newer than this dolly supports (%d); upgrade dolly", res.fromVersion, CurrentVersion)
+	}
+
+	var rawSessions []json.RawMessage
+	if raw, ok := doc["sessions"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &rawSessions); err != nil {
+			res.quarantined = append(res.quarantined, raw)
+		}
+	}
+
+	// Decode each entry generically so migrations can reshape it
+	generic := make([]map[string]interface{}, 0, len(rawSessions))
+	for _, raw := range rawSessions {
+		var m map[string]interface{}
+		if err := json.Unmarshal(raw, &m); err != nil || m == nil {
+			res.quarantined = append(res.quarantined, raw)
+			continue
+		}
+		generic = append(generic, m)
+	}
+	generic = migrate(res.fromVersion, generic)
+
+	res.reg = &Registry{Version: CurrentVersion, Sessions: []Entry{}}
+	for _, m := range generic {
+		raw, _ := json.Marshal(m)
+		var e Entry
+		if err := json.Unmarshal(raw, &e); err != nil {
+			res.quarantined = append(res.quarantined, raw)
+			continue
+		}
+		res.reg.Sessions = append(res.reg.Sessions, e)
+	}
+	return res, nil
+}
+
+// backupPath returns a sibling of path tagged with a label, e.g.
+// registry.json → registry.v1.bak.json.
+func backupPath(path, label string) string {
+	ext := filepath.Ext(path)
+	return path[:len(path)-len(ext)] + "." + label + ext
+}
+
+// writeBackup copies the original registry bytes next to registry.json before
+// they are rewritten. An existing backup for the same label is kept.
+func writeBackup(path, label string, data []byte) (string, error) {
+	dst := backupPath(path, label)
+	if _, err := os.Stat(dst); err == nil {
+		return dst, nil
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return "", fmt.Errorf("could not back up registry: %w", err)
+	}
+	return dst, nil
+}
+
+// writeQuarantine stores entries that could not be decoded in a timestamped
+// file so they can be inspected or repaired by hand.
+func writeQuarantine(path string, entries []json.RawMessage) (string, error) {
+	dst := backupPath(path, "quarantine-"+time.Now().Format("20060102-150405"))
+	data, err := json.MarshalIndent(map[string]interface{}{"sessions": entries}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return "", fmt.Errorf("could not write quarantine file: %w", err)
+	}
+	return dst, nil
+}
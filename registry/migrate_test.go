@@ -0,0 +1,140 @@
+package registry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRawRegistry(t *testing.T, content string) string {
+	t.Helper()
+	path, err := registryPath()
+	if err != nil {
+		t.Fatalf("registryPath: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write registry: %v", err)
+	}
+	return path
+}
+
+func globCount(t *testing.T, dir, pattern string) int {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	return len(matches)
+}
+
+// ─── Migration: unversioned file is upgraded with a backup ───────────────────
+
+func TestLoad_MigratesUnversioned(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	legacy := `{"sessions":[
+		{"name":"old-yaml","type":"yaml","created_at":"2024-01-01T00:00:00Z","last_active":"2024-01-01T00:00:00Z","working_dir":"/tmp","windows":1,"terminal":"zsh"},
+		{"name":"untyped","created_at":"2024-01-01T00:00:00Z","last_active":"2024-01-01T00:00:00Z","working_dir":"/tmp","windows":1,"terminal":"zsh"},
+		{"name":"","type":"exec"}
+	]}`
+	path := writeRawRegistry(t, legacy)
+
+	reg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if reg.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", reg.Version, CurrentVersion)
+	}
+	if len(reg.Sessions) != 2 {
+		t.Fatalf("expected 2 sessions after migration, got %d", len(reg.Sessions))
+	}
+	if reg.Sessions[1].Type != TypeAttached {
+		t.Errorf("untyped entry: Type = %q, want %q", reg.Sessions[1].Type, TypeAttached)
+	}
+
+	backup, err := os.ReadFile(backupPath(path, "v1.bak"))
+	if err != nil {
+		t.Fatalf("expected v1 backup: %v", err)
+	}
+	if string(backup) != legacy {
+		t.Error("backup does not match the original file")
+	}
+
+	// The upgraded file is persisted with the current version
+	data, _ := os.ReadFile(path)
+	var onDisk Registry
+	if err := json.Unmarshal(data, &onDisk); err != nil || onDisk.Version != CurrentVersion {
+		t.Fatalf("expected upgraded file on disk, got version %d (err %v)", onDisk.Version, err)
+	}
+}
+
+// ─── Recovery: valid entries are salvaged, bad ones quarantined ──────────────
+
+func TestLoad_SalvagesValidEntries(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	path := writeRawRegistry(t, `{"version":2,"sessions":[
+		{"name":"good","type":"yaml","created_at":"2024-01-01T00:00:00Z","last_active":"2024-01-01T00:00:00Z","working_dir":"/tmp","windows":1,"terminal":"zsh"},
+		{"name":"bad-time","type":"yaml","created_at":"yesterday"},
+		"garbage"
+	]}`)
+
+	reg, err := Load()
+	if err != nil {
+		t.Fatalf("Load should salvage, got error: %v", err)
+	}
+	if len(reg.Sessions) != 1 || reg.Sessions[0].Name != "good" {
+		t.Fatalf("expected only 'good' to survive, got %v", reg.Sessions)
+	}
+
+	dir := filepath.Dir(path)
+	if n := globCount(t, dir, "registry.quarantine-*.json"); n != 1 {
+		t.Fatalf("expected 1 quarantine file, got %d", n)
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "registry.quarantine-*.json"))
+	data, _ := os.ReadFile(matches[0])
+	if !strings.Contains(string(data), "bad-time") || !strings.Contains(string(data), "garbage") {
+		t.Errorf("quarantine file missing bad entries:\n%s", data)
+	}
+
+	// A second load finds a clean file and quarantines nothing new
+	if _, err := Load(); err != nil {
+		t.Fatalf("second Load: %v", err)
+	}
+	if n := globCount(t, dir, "registry.quarantine-*.json"); n != 1 {
+		t.Fatalf("expected still 1 quarantine file, got %d", n)
+	}
+}
+
+// ─── Recovery: unparseable file is moved aside ───────────────────────────────
+
+func TestLoad_UnreadableFile(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	path := writeRawRegistry(t, `{"sessions": [ this is not json`)
+
+	reg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(reg.Sessions) != 0 {
+		t.Fatalf("expected empty registry, got %d sessions", len(reg.Sessions))
+	}
+	if n := globCount(t, filepath.Dir(path), "registry.corrupt-*.json"); n != 1 {
+		t.Fatalf("expected corrupt copy to be kept, found %d", n)
+	}
+}
+
+// ─── Future versions are refused, not overwritten ────────────────────────────
+
+func TestLoad_NewerVersion(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	writeRawRegistry(t, `{"version":99,"sessions":[]}`)
+	if _, err := Load(); err == nil {
+		t.Fatal("expected error for a registry written by a newer dolly")
+	}
+}
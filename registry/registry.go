@@ -3,13 +3,12 @@ package registry
 import (
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"time"
 
 	"tmux-manager/internal/dolly"
+	"tmux-manager/tmux"
 )
 
 // registryPath returns the absolute path to ~/.dolly/registry.json,
@@ -22,30 +21,124 @@ func registryPath() (string, error) {
 	return filepath.Join(dir, "registry.json"), nil
 }
 
+// lockPath returns the absolute path to ~/.dolly/registry.lock.
+func lockPath() (string, error) {
+	dir, err := dolly.DataDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine dolly data directory: %w", err)
+	}
+	return filepath.Join(dir, "registry.lock"), nil
+}
+
+// lockRegistry takes an exclusive lock on ~/.dolly/registry.lock for a
+// read-modify-write cycle and returns a function that releases it.
+func lockRegistry() (unlock func(), err error) {
+	path, err := lockPath()
+	if err != nil {
+		return nil, err
+	}
+	return dolly.LockFile(path)
+}
+
 // Load reads the registry from disk. Returns an empty Registry (not an error)
 // when the file does not yet exist.
+//
+// Older schema versions are upgraded step by step (see migrations) after a
+// backup copy is written. Entries that cannot be decoded are moved to a
+// quarantine file and the valid ones are kept, so one bad record never costs
+// the whole session history. Load only writes when such a repair is needed,
+// and then under the registry lock with the file re-read, so it never
+// overwrites a registry a concurrent Update has just saved.
 func Load() (*Registry, error) {
+	res, _, err := readRegistry()
+	if err != nil {
+		return nil, err
+	}
+	if !res.needsRepair() {
+		return res.reg, nil
+	}
+
+	unlock, err := lockRegistry()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return loadLocked()
+}
+
+// loadLocked is Load for callers that already hold the registry lock. It
+// performs and saves any upgrade or salvage the file needs.
+func loadLocked() (*Registry, error) {
+	res, data, err := readRegistry()
+	if err != nil {
+		return nil, err
+	}
+	if !res.needsRepair() {
+		return res.reg, nil
+	}
 	path, err := registryPath()
 	if err != nil {
 		return nil, err
 	}
+	stamp := time.Now().Format("20060102-150405")
+
+	switch {
+	case res.unreadable:
+		dst, berr := writeBackup(path, "corrupt-"+stamp, data)
+		if berr != nil {
+			return nil, fmt.Errorf("registry is corrupted and could not be moved aside: %w", berr)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: registry was not valid JSON; saved a copy to %s and started a new registry\n", dst)
+
+	case len(res.quarantined) > 0:
+		if _, berr := writeBackup(path, "bak-"+stamp, data); berr != nil {
+			return nil, berr
+		}
+		dst, qerr := writeQuarantine(path, res.quarantined)
+		if qerr != nil {
+			return nil, qerr
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %d unreadable registry %s moved to %s; %d kept\n",
+			len(res.quarantined), pluralEntries(len(res.quarantined)), dst, len(res.reg.Sessions))
+
+	default:
+		if _, berr := writeBackup(path, fmt.Sprintf("v%d.bak", res.fromVersion), data); berr != nil {
+			return nil, berr
+		}
+	}
 
+	if err := Save(res.reg); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save repaired registry: %v\n", err)
+	}
+	return res.reg, nil
+}
+
+// readRegistry reads and decodes registry.json without writing anything. A
+// missing file decodes as an empty registry.
+func readRegistry() (*decodeResult, []byte, error) {
+	path, err := registryPath()
+	if err != nil {
+		return nil, nil, err
+	}
 	data, err := os.ReadFile(path)
 	if os.IsNotExist(err) {
-		return &Registry{Sessions: []Entry{}}, nil
+		return &decodeResult{reg: &Registry{Version: CurrentVersion, Sessions: []Entry{}}, fromVersion: CurrentVersion}, nil, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("could not read registry: %w", err)
+		return nil, nil, fmt.Errorf("could not read registry: %w", err)
 	}
-
-	var reg Registry
-	if err := json.Unmarshal(data, &reg); err != nil {
-		return nil, fmt.Errorf("registry is corrupted (%w); delete ~/.dolly/registry.json to reset", err)
+	res, err := decode(data)
+	if err != nil {
+		return nil, nil, err
 	}
-	if reg.Sessions == nil {
-		reg.Sessions = []Entry{}
+	return res, data, nil
+}
+
+func pluralEntries(n int) string {
+	if n == 1 {
+		return "entry"
 	}
-	return &reg, nil
+	return "entries"
 }
 
 // Save writes the registry to disk atomically via a temp file + rename to
@@ -56,6 +149,7 @@ func Save(reg *Registry) error {
 		return err
 	}
 
+	reg.Version = CurrentVersion
 	data, err := json.MarshalIndent(reg, "", "  ")
 	if err != nil {
 		return fmt.Errorf("could not serialize registry: %w", err)
@@ -85,66 +179,108 @@ func Save(reg *Registry) error {
 	return nil
 }
 
-// AddEntry upserts an entry into the registry: if an entry with the same name
-// already exists it is replaced; otherwise the entry is appended.
-func AddEntry(entry Entry) error {
-	reg, err := Load()
+// Update runs a Load → modify → Save cycle while holding the registry lock,
+// so concurrent dolly invocations cannot lose each other's writes. If fn
+// returns an error nothing is saved.
+func Update(fn func(reg *Registry) error) error {
+	unlock, err := lockRegistry()
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	replaced := false
-	for i, s := range reg.Sessions {
-		if s.Name == entry.Name {
-			reg.Sessions[i] = entry
-			replaced = true
-			break
-		}
+	reg, err := loadLocked()
+	if err != nil {
+		return err
 	}
-	if !replaced {
-		reg.Sessions = append(reg.Sessions, entry)
+	if err := fn(reg); err != nil {
+		return err
 	}
-
 	return Save(reg)
 }
 
+// AddEntry upserts an entry into the registry: if an entry with the same name
+// already exists it is replaced; otherwise the entry is appended. Recreating a
+// session keeps what the user attached to it: tags added by hand are merged,
+// while ConfigTags replace the tags the previous config set, so a tag removed
+// from the YAML goes away. The note carries over unless the new entry sets
+// one, the original owner is kept, and protection is only ever removed with
+// `dolly unprotect`.
+func AddEntry(entry Entry) error {
+	if entry.Owner == "" {
+		entry.Owner = dolly.CurrentUser()
+	}
+	entry.ConfigTags = ApplyTags(nil, entry.ConfigTags, nil)
+	entry.Tags = ApplyTags(entry.Tags, entry.ConfigTags, nil)
+	return Update(func(reg *Registry) error {
+		replaced := false
+		for i, s := range reg.Sessions {
+			if s.Name == entry.Name {
+				manual := ApplyTags(s.Tags, nil, s.ConfigTags)
+				entry.Tags = ApplyTags(manual, entry.Tags, nil)
+				if entry.Note == "" {
+					entry.Note = s.Note
+				}
+				if s.Owner != "" {
+					entry.Owner = s.Owner
+				}
+				entry.Protected = entry.Protected || s.Protected
+				reg.Sessions[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			reg.Sessions = append(reg.Sessions, entry)
+		}
+		return nil
+	})
+}
+
 // RemoveEntry removes the entry with the given name. Returns nil if the entry
 // was not found (callers can decide whether to warn the user).
 func RemoveEntry(name string) error {
-	reg, err := Load()
-	if err != nil {
-		return err
-	}
-
-	filtered := reg.Sessions[:0]
-	for _, s := range reg.Sessions {
-		if s.Name != name {
-			filtered = append(filtered, s)
+	return Update(func(reg *Registry) error {
+		filtered := reg.Sessions[:0]
+		for _, s := range reg.Sessions {
+			if s.Name != name {
+				filtered = append(filtered, s)
+			}
 		}
-	}
-	reg.Sessions = filtered
-
-	return Save(reg)
+		reg.Sessions = filtered
+		return nil
+	})
 }
 
-// isSessionAlive probes tmux without leaking output to the user's terminal.
-func isSessionAlive(name string) bool {
-	cmd := exec.Command("tmux", "has-session", "-t", name)
-	cmd.Stdout = io.Discard
-	cmd.Stderr = io.Discard
-	return cmd.Run() == nil
+// liveSessions returns the running tmux sessions and their last activity. It
+// is fetched once per call so a registry with hundreds of entries costs one
+// tmux invocation; tests replace it to simulate live sessions.
+var liveSessions = func() map[string]time.Time {
+	live := tmux.ListSessionActivity()
+	times := make(map[string]time.Time, len(live))
+	for name, a := range live {
+		times[name] = a.Activity
+	}
+	return times
 }
 
 // ListSessions returns all sessions, optionally filtered by type.
-// It probes each session for liveness, updates LastActive for alive ones,
-// and performs a single Save at the end.
+// It fetches the live session set once, sets LastActive of alive ones from
+// tmux's own activity timestamp, and performs a single Save at the end.
 func ListSessions(typeFilter ...SessionType) ([]SessionStatus, error) {
-	reg, err := Load()
+	unlock, err := lockRegistry()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	reg, err := loadLocked()
 	if err != nil {
 		return nil, err
 	}
 
 	filter := toSet(typeFilter)
+	live := liveSessions()
 	statuses := make([]SessionStatus, 0)
 	dirty := false
 
@@ -152,9 +288,8 @@ func ListSessions(typeFilter ...SessionType) ([]SessionStatus, error) {
 		if len(filter) > 0 && !filter[s.Type] {
 			continue
 		}
-		alive := isSessionAlive(s.Name)
-		if alive {
-			reg.Sessions[i].LastActive = time.Now()
+		activity, alive := live[s.Name]
+		if alive && touch(&reg.Sessions[i], activity) {
 			dirty = true
 		}
 		statuses = append(statuses, SessionStatus{Entry: reg.Sessions[i], Alive: alive})
@@ -173,13 +308,20 @@ func ListSessions(typeFilter ...SessionType) ([]SessionStatus, error) {
 // LastActive timestamp is older than olderThanDays days. Optionally filtered
 // by session type. Returns the names of removed entries.
 func CleanupStale(olderThanDays int, typeFilter ...SessionType) ([]string, error) {
-	reg, err := Load()
+	unlock, err := lockRegistry()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	reg, err := loadLocked()
 	if err != nil {
 		return nil, err
 	}
 
 	threshold := time.Now().AddDate(0, 0, -olderThanDays)
 	filter := toSet(typeFilter)
+	live := liveSessions()
 	var removed []string
 	var kept []Entry
 
@@ -190,8 +332,7 @@ func CleanupStale(olderThanDays int, typeFilter ...SessionType) ([]string, error
 			continue
 		}
 
-		alive := isSessionAlive(s.Name)
-		if !alive && s.LastActive.Before(threshold) {
+		if _, alive := live[s.Name]; !alive && s.LastActive.Before(threshold) {
 			removed = append(removed, s.Name)
 		} else {
 			kept = append(kept, s)
@@ -210,6 +351,31 @@ func CleanupStale(olderThanDays int, typeFilter ...SessionType) ([]string, error
 	return removed, nil
 }
 
+// touch moves an alive entry's LastActive forward to the session's last tmux
+// activity, or to now when tmux did not report one. Reports whether the
+// entry changed.
+func touch(e *Entry, activity time.Time) bool {
+	if activity.IsZero() {
+		activity = time.Now()
+	}
+	if !activity.After(e.LastActive) {
+		return false
+	}
+	e.LastActive = activity
+	return true
+}
+
+// RecordActivity refreshes LastActive of registered sessions from the last
+// activity of each live session; a zero time means "now". Entries not in
+// live are left alone.
+func RecordActivity(reg *Registry, live map[string]time.Time) {
+	for i := range reg.Sessions {
+		if activity, ok := live[reg.Sessions[i].Name]; ok {
+			touch(&reg.Sessions[i], activity)
+		}
+	}
+}
+
 // toSet converts a slice of SessionType into a lookup map
 func toSet(types []SessionType) map[SessionType]bool {
 	if len(types) == 0 {
@@ -162,7 +162,7 @@ func TestCleanupStale(t *testing.T) {
 	AddEntry(aliveOld)
 
 	// CleanupStale with threshold=7 days
-	// We fake isSessionAlive by testing with entries whose names won't exist in tmux.
+	// We fake liveness by testing with entries whose names won't exist in tmux.
 	// "alive-old" is tmux-dead too, but LastActive is also 8 days old — however
 	// the test environment has no tmux so ALL sessions are "dead".
 	// Adjust: make aliveOld's LastActive only 2 days old so it survives threshold.
@@ -265,3 +265,97 @@ func TestListSessions_TypeFilter(t *testing.T) {
 		t.Fatalf("expected [tw-1], got %v", throwaway)
 	}
 }
+
+// stubLiveSessions makes the given names the only running tmux sessions for
+// the duration of a test and counts how often liveness is queried.
+func stubLiveSessions(t *testing.T, names ...string) *int {
+	t.Helper()
+	calls := 0
+	orig := liveSessions
+	liveSessions = func() map[string]time.Time {
+		calls++
+		live := make(map[string]time.Time, len(names))
+		for _, n := range names {
+			live[n] = time.Time{}
+		}
+		return live
+	}
+	t.Cleanup(func() { liveSessions = orig })
+	return &calls
+}
+
+// ─── ListSessions: exact names, one liveness query ───────────────────────────
+
+func TestListSessions_ExactNameMatch(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	AddEntry(makeEntry("work", TypeYAML, 0, false))
+	AddEntry(makeEntry("work-api", TypeYAML, 0, true))
+	AddEntry(makeEntry("scratch", TypeThrowaway, 0, false))
+	calls := stubLiveSessions(t, "work-api")
+
+	statuses, err := ListSessions()
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("expected 1 liveness query, got %d", *calls)
+	}
+	alive := map[string]bool{}
+	for _, s := range statuses {
+		alive[s.Name] = s.Alive
+	}
+	// "work" is a prefix of the live "work-api" but must not count as alive
+	if alive["work"] || !alive["work-api"] || alive["scratch"] {
+		t.Fatalf("unexpected liveness: %v", alive)
+	}
+}
+
+// ─── CleanupStale: prefix of a live session is still dead ────────────────────
+
+func TestCleanupStale_PrefixNameIsDead(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	AddEntry(makeEntry("work", TypeThrowaway, 10, false))
+	AddEntry(makeEntry("work-api", TypeThrowaway, 10, true))
+	calls := stubLiveSessions(t, "work-api")
+
+	removed, err := CleanupStale(7)
+	if err != nil {
+		t.Fatalf("CleanupStale: %v", err)
+	}
+	if *calls != 1 {
+		t.Errorf("expected 1 liveness query, got %d", *calls)
+	}
+	if len(removed) != 1 || removed[0] != "work" {
+		t.Fatalf("expected [work] removed, got %v", removed)
+	}
+}
+
+// ─── ListSessions: LastActive follows tmux activity ──────────────────────────
+
+func TestListSessions_LastActiveFromActivity(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	AddEntry(makeEntry("idle", TypeYAML, 10, true))
+	activity := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	orig := liveSessions
+	liveSessions = func() map[string]time.Time {
+		return map[string]time.Time{"idle": activity}
+	}
+	defer func() { liveSessions = orig }()
+
+	statuses, err := ListSessions()
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if !statuses[0].Alive || !statuses[0].LastActive.Equal(activity) {
+		t.Fatalf("LastActive = %v, want tmux activity %v", statuses[0].LastActive, activity)
+	}
+
+	// Listing again must not move LastActive to "now"
+	statuses, _ = ListSessions()
+	if !statuses[0].LastActive.Equal(activity) {
+		t.Fatalf("LastActive drifted to %v", statuses[0].LastActive)
+	}
+}
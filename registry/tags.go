@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidateTag reports whether a tag can be stored. Tags are single words so
+// they can be passed on the command line as +tag / -tag and listed
+// comma-separated.
+func ValidateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("tag must not be empty")
+	}
+	if strings.ContainsAny(tag, " \t\n,") {
+		return fmt.Errorf("invalid tag %q: tags cannot contain spaces or commas", tag)
+	}
+	if strings.HasPrefix(tag, "+") || strings.HasPrefix(tag, "-") {
+		return fmt.Errorf("invalid tag %q: tags cannot start with + or -", tag)
+	}
+	return nil
+}
+
+// ApplyTags returns tags with add appended and remove taken out, deduplicated
+// and sorted so the registry diff stays stable.
+func ApplyTags(tags, add, remove []string) []string {
+	set := make(map[string]bool, len(tags)+len(add))
+	for _, t := range tags {
+		set[t] = true
+	}
+	for _, t := range add {
+		set[t] = true
+	}
+	for _, t := range remove {
+		delete(set, t)
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(set))
+	for t := range set {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// HasTags reports whether the entry carries every one of the given tags.
+func (e Entry) HasTags(tags []string) bool {
+	for _, want := range tags {
+		found := false
+		for _, t := range e.Tags {
+			if t == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateEntry applies fn to the entry with the given name under the registry
+// lock. Returns an error when no such entry is registered.
+func UpdateEntry(name string, fn func(e *Entry)) error {
+	return Update(func(reg *Registry) error {
+		for i := range reg.Sessions {
+			if reg.Sessions[i].Name == name {
+				fn(&reg.Sessions[i])
+				return nil
+			}
+		}
+		return fmt.Errorf("session %q is not in the registry", name)
+	})
+}
@@ -0,0 +1,157 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ─── ApplyTags ───────────────────────────────────────────────────────────────
+
+func TestApplyTags(t *testing.T) {
+	cases := []struct {
+		tags, add, remove, want []string
+	}{
+		{nil, []string{"backend"}, nil, []string{"backend"}},
+		{[]string{"urgent", "backend"}, []string{"api"}, []string{"urgent"}, []string{"api", "backend"}},
+		{[]string{"a"}, []string{"a", "a"}, nil, []string{"a"}},
+		{[]string{"a"}, nil, []string{"a"}, nil},
+		{[]string{"a"}, nil, []string{"missing"}, []string{"a"}},
+	}
+	for _, c := range cases {
+		if got := ApplyTags(c.tags, c.add, c.remove); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ApplyTags(%v, +%v, -%v) = %v, want %v", c.tags, c.add, c.remove, got, c.want)
+		}
+	}
+}
+
+func TestValidateTag(t *testing.T) {
+	for _, good := range []string{"backend", "q3-launch", "team/infra"} {
+		if err := ValidateTag(good); err != nil {
+			t.Errorf("ValidateTag(%q) unexpected error: %v", good, err)
+		}
+	}
+	for _, bad := range []string{"", "two words", "a,b", "+x", "-x"} {
+		if err := ValidateTag(bad); err == nil {
+			t.Errorf("ValidateTag(%q) should fail", bad)
+		}
+	}
+}
+
+func TestHasTags(t *testing.T) {
+	e := Entry{Tags: []string{"api", "backend"}}
+	if !e.HasTags([]string{"backend"}) || !e.HasTags([]string{"api", "backend"}) || !e.HasTags(nil) {
+		t.Error("expected entry to match its own tags")
+	}
+	if e.HasTags([]string{"backend", "urgent"}) {
+		t.Error("all requested tags must be present")
+	}
+}
+
+// ─── AddEntry keeps user metadata across re-creation ─────────────────────────
+
+func TestAddEntry_PreservesTagsAndNote(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	first := makeEntry("api", TypeYAML, 1, true)
+	first.Tags = []string{"backend"}
+	first.Note = "payments refactor"
+	first.Owner = "alice"
+	AddEntry(first)
+
+	// Re-creating from YAML adds a tag but knows nothing about the note
+	again := makeEntry("api", TypeYAML, 0, true)
+	again.Tags = []string{"team-pay"}
+	AddEntry(again)
+
+	reg, _ := Load()
+	if len(reg.Sessions) != 1 {
+		t.Fatalf("expected 1 session, got %d", len(reg.Sessions))
+	}
+	got := reg.Sessions[0]
+	if !reflect.DeepEqual(got.Tags, []string{"backend", "team-pay"}) {
+		t.Errorf("Tags = %v, want merged [backend team-pay]", got.Tags)
+	}
+	if got.Note != "payments refactor" {
+		t.Errorf("Note = %q, want it carried over", got.Note)
+	}
+	if got.Owner != "alice" {
+		t.Errorf("Owner = %q, want original owner kept", got.Owner)
+	}
+}
+
+func TestAddEntry_ReplacesConfigTags(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	first := makeEntry("api", TypeYAML, 1, true)
+	first.ConfigTags = []string{"backend", "legacy"}
+	AddEntry(first)
+	UpdateEntry("api", func(e *Entry) { e.Tags = ApplyTags(e.Tags, []string{"urgent"}, nil) })
+
+	// The YAML dropped legacy: it goes, the hand-added tag stays
+	again := makeEntry("api", TypeYAML, 0, true)
+	again.ConfigTags = []string{"backend", "team-pay"}
+	AddEntry(again)
+
+	reg, _ := Load()
+	got := reg.Sessions[0]
+	if !reflect.DeepEqual(got.Tags, []string{"backend", "team-pay", "urgent"}) {
+		t.Errorf("Tags = %v, want [backend team-pay urgent]", got.Tags)
+	}
+	if !reflect.DeepEqual(got.ConfigTags, []string{"backend", "team-pay"}) {
+		t.Errorf("ConfigTags = %v, want [backend team-pay]", got.ConfigTags)
+	}
+}
+
+func TestAddEntry_RecordsOwner(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	AddEntry(makeEntry("mine", TypeExec, 0, true))
+	reg, _ := Load()
+	if reg.Sessions[0].Owner == "" {
+		t.Error("expected Owner to default to the current user")
+	}
+}
+
+func TestAddEntry_KeepsProtection(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	first := makeEntry("migration", TypeYAML, 1, true)
+	first.Protected = true
+	AddEntry(first)
+
+	// A YAML without protected: true must not silently drop protection
+	AddEntry(makeEntry("migration", TypeYAML, 0, true))
+	reg, _ := Load()
+	if !reg.Sessions[0].Protected {
+		t.Fatal("expected protection to survive re-creation")
+	}
+
+	UpdateEntry("migration", func(e *Entry) { e.Protected = false })
+	reg, _ = Load()
+	if reg.Sessions[0].Protected {
+		t.Error("UpdateEntry should be able to clear protection")
+	}
+}
+
+// ─── UpdateEntry ─────────────────────────────────────────────────────────────
+
+func TestUpdateEntry(t *testing.T) {
+	defer setupTestRegistry(t)()
+
+	AddEntry(makeEntry("api", TypeYAML, 0, true))
+	err := UpdateEntry("api", func(e *Entry) {
+		e.Tags = ApplyTags(e.Tags, []string{"urgent"}, nil)
+		e.Note = "on call"
+	})
+	if err != nil {
+		t.Fatalf("UpdateEntry: %v", err)
+	}
+	reg, _ := Load()
+	if got := reg.Sessions[0]; got.Note != "on call" || !got.HasTags([]string{"urgent"}) {
+		t.Fatalf("update not persisted: %+v", got)
+	}
+
+	if err := UpdateEntry("ghost", func(e *Entry) {}); err == nil {
+		t.Fatal("expected error for unregistered session")
+	}
+}
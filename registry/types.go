@@ -16,18 +16,43 @@ const (
 
 // Entry represents one registered dolly session
 type Entry struct {
-	Name       string      `json:"name"`
-	Type       SessionType `json:"type"`
-	CreatedAt  time.Time   `json:"created_at"`
-	LastActive time.Time   `json:"last_active"`
-	WorkingDir string      `json:"working_dir"`
-	ConfigFile string      `json:"config_file,omitempty"` // absolute path to .yml (yaml mode only)
-	Windows    int         `json:"windows"`
-	Terminal   string      `json:"terminal"`
+	Name         string      `json:"name"`
+	Type         SessionType `json:"type"`
+	CreatedAt    time.Time   `json:"created_at"`
+	LastActive   time.Time   `json:"last_active"`
+	WorkingDir   string      `json:"working_dir"`
+	ConfigFile   string      `json:"config_file,omitempty"` // absolute path to .yml (yaml mode only)
+	Windows      int         `json:"windows"`
+	Terminal     string      `json:"terminal"`
+	Structure    []Window    `json:"structure,omitempty"`     // window/pane snapshot (attached sessions)
+	Tags         []string    `json:"tags,omitempty"`          // free-form labels, sorted
+	ConfigTags   []string    `json:"config_tags,omitempty"`   // the part of Tags set by the YAML config's tags:
+	Note         string      `json:"note,omitempty"`          // one-line description set with `dolly note`
+	Owner        string      `json:"owner,omitempty"`         // login name of the user who registered the session
+	TTL          string      `json:"ttl,omitempty"`           // throwaway: maximum lifetime, e.g. "4h"
+	Idle         string      `json:"idle,omitempty"`          // throwaway: maximum time without tmux activity
+	WorktreePath string      `json:"worktree_path,omitempty"` // throwaway: git worktree created for the session
+	WorktreeRepo string      `json:"worktree_repo,omitempty"` // throwaway: repository the worktree belongs to
+	Protected    bool        `json:"protected,omitempty"`     // dolly refuses to kill the session without -force
+}
+
+// Window is one window of a session's recorded structure
+type Window struct {
+	Name   string `json:"name"`
+	Layout string `json:"layout,omitempty"` // tmux layout string
+	Panes  []Pane `json:"panes"`
+}
+
+// Pane is one pane of a recorded window
+type Pane struct {
+	WorkingDir string `json:"working_dir"`
+	Command    string `json:"command,omitempty"` // empty for an idle shell
+	Title      string `json:"title,omitempty"`
 }
 
 // Registry is the top-level JSON document stored at ~/.dolly/registry.json
 type Registry struct {
+	Version  int     `json:"version"` // schema version; see CurrentVersion
 	Sessions []Entry `json:"sessions"`
 }
 
@@ -0,0 +1,192 @@
+package shortcuts
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Problem severities reported by Check.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is one finding from Check. Shortcut is empty for a problem with
+// the file as a whole.
+type Problem struct {
+	Shortcut string
+	Severity string
+	Message  string
+}
+
+// Check renders a session's shortcuts file for terminal into a scratch
+// directory and parses it with the target shell without running it. A file
+// that fails to parse is narrowed down to the shortcuts that break it, since
+// one broken function makes the whole source line fail in every pane. Each
+// shortcut is also linted: names that shadow a builtin or a command on PATH,
+// and positional arguments used without quotes.
+func Check(sessionName, terminal string, shortcuts map[string]Shortcut) ([]Problem, error) {
+	problems := lint(shellKind(terminal), shortcuts)
+
+	dir, err := os.MkdirTemp("", "dolly-check-")
+	if err != nil {
+		return problems, fmt.Errorf("could not create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := writeShellFileIn(dir, sessionName, terminal, shortcuts)
+	if err != nil {
+		return problems, err
+	}
+	bin, args := syntaxCommand(terminal, path)
+	if _, err := exec.LookPath(bin); err != nil {
+		return append(problems, Problem{Severity: SeverityWarning,
+			Message: fmt.Sprintf("%s is not installed; syntax not checked", bin)}), nil
+	}
+	msg := parseFile(bin, args, path)
+	if msg == "" {
+		return problems, nil
+	}
+
+	// Render each shortcut alone to find the ones the shell rejects
+	found := false
+	for _, name := range sortedNames(shortcuts) {
+		one := map[string]Shortcut{name: shortcuts[name]}
+		if _, err := writeShellFileIn(dir, sessionName, terminal, one); err != nil {
+			return problems, err
+		}
+		if m := parseFile(bin, args, path); m != "" {
+			problems = append(problems, Problem{Shortcut: name, Severity: SeverityError, Message: m})
+			found = true
+		}
+	}
+	if !found {
+		problems = append(problems, Problem{Severity: SeverityError, Message: msg})
+	}
+	return problems, nil
+}
+
+// syntaxCommand returns the command that parses the file at path without
+// running it. Terminals other than fish, nu and POSIX sh get the bash/zsh
+// file, so bash checks it unless the terminal is zsh.
+func syntaxCommand(terminal, path string) (bin string, args []string) {
+	switch shellKind(terminal) {
+	case ShellFish:
+		return "fish", []string{"-n", path}
+	case ShellNu:
+		return "nu", []string{"--no-config-file", "-c", fmt.Sprintf("nu-check --debug %q", path)}
+	case ShellSh:
+		return terminal, []string{"-n", path}
+	}
+	if terminal == "zsh" {
+		return "zsh", []string{"-n", path}
+	}
+	return "bash", []string{"-n", path}
+}
+
+// parseFile runs the syntax check and returns the shell's first complaint,
+// or "" if the file parses.
+func parseFile(bin string, args []string, path string) string {
+	out, err := exec.Command(bin, args...).CombinedOutput()
+	if err == nil {
+		return ""
+	}
+	text := strings.ReplaceAll(string(out), path+": ", "")
+	text = strings.ReplaceAll(text, path, "shortcuts file")
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return err.Error()
+}
+
+// lint checks each shortcut on its own, without running any shell.
+func lint(kind string, shortcuts map[string]Shortcut) []Problem {
+	var problems []Problem
+	for _, name := range sortedNames(shortcuts) {
+		add := func(severity, format string, a ...interface{}) {
+			problems = append(problems, Problem{Shortcut: name, Severity: severity, Message: fmt.Sprintf(format, a...)})
+		}
+		if !validName.MatchString(name) {
+			add(SeverityError, "not a valid shell identifier (letters, digits, underscores; no hyphens)")
+		}
+		if shellBuiltins[name] {
+			add(SeverityWarning, "shadows a shell builtin")
+		} else if path, found := onPath(name); found {
+			add(SeverityWarning, "shadows %s on PATH", path)
+		}
+		// fish does not split variables into words, so only POSIX bodies care
+		if kind != ShellFish {
+			if refs := unquotedPositionals(shortcuts[name].Command); len(refs) > 0 {
+				add(SeverityWarning, "unquoted %s: arguments containing spaces are split into several words", strings.Join(refs, ", "))
+			}
+		}
+	}
+	return problems
+}
+
+// unquotedPositionals returns the positional references ($1, ${2:-x}, $@,
+// $*) in command that are outside any quotes, in order and without repeats.
+// It scans quotes the way ToFish does; command substitutions inside double
+// quotes are treated as quoted.
+func unquotedPositionals(command string) []string {
+	var refs []string
+	seen := map[string]bool{}
+	inSingle, inDouble := false, false
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == '\\' && !inSingle:
+			i++
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		case c == '$' && !inSingle && !inDouble && i+1 < len(command):
+			if ref := positionalAt(command[i:]); ref != "" {
+				if !seen[ref] {
+					refs = append(refs, ref)
+					seen[ref] = true
+				}
+				i += len(ref) - 1
+			}
+		}
+	}
+	return refs
+}
+
+// positionalAt returns the positional reference at the start of s, which
+// begins with '$', or "" if there is none.
+func positionalAt(s string) string {
+	switch c := s[1]; {
+	case c >= '1' && c <= '9', c == '@', c == '*':
+		return s[:2]
+	case c != '{':
+		return ""
+	}
+	end := strings.IndexByte(s, '}')
+	if end < 0 || len(s) < 3 || s[2] < '1' || s[2] > '9' {
+		return ""
+	}
+	return s[:end+1]
+}
+
+// onPath returns the command that name resolves to on PATH, if any.
+func onPath(name string) (string, bool) {
+	path, err := exec.LookPath(name)
+	return path, err == nil
+}
+
+func sortedNames(shortcuts map[string]Shortcut) []string {
+	names := make([]string, 0, len(shortcuts))
+	for name := range shortcuts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,118 @@
+package shortcuts
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnquotedPositionals(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{`grep -rn "$1" "${2:-.}"`, nil},
+		{`cp $1 ${2} $1`, []string{"$1", "${2}"}},
+		{`echo $@ "$*"`, []string{"$@"}},
+		{`du -sh ${1:-.}`, []string{"${1:-.}"}},
+		{`echo '$1' \$2 $HOME ${PATH}`, nil},
+		{`echo "it's" $3`, []string{"$3"}},
+	}
+	for _, tt := range tests {
+		if got := unquotedPositionals(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("unquotedPositionals(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultsPassLint(t *testing.T) {
+	for _, p := range lint("bash", Defaults) {
+		if !strings.HasPrefix(p.Message, "shadows") {
+			t.Errorf("built-in %s: %s", p.Shortcut, p.Message)
+		}
+	}
+}
+
+func TestCheckFindsBrokenShortcut(t *testing.T) {
+	for _, shell := range []string{"bash", "dash"} {
+		if _, err := exec.LookPath(shell); err != nil {
+			continue
+		}
+		t.Run(shell, func(t *testing.T) {
+			sc := map[string]Shortcut{
+				"gs":     {Command: "git status"},
+				"broken": {Command: `if [ -n "$1" ]; then echo yes`},
+			}
+			problems, err := Check("meta", shell, sc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var errs []Problem
+			for _, p := range problems {
+				if p.Severity == SeverityError {
+					errs = append(errs, p)
+				}
+			}
+			if len(errs) != 1 || errs[0].Shortcut != "broken" {
+				t.Fatalf("want one error for broken, got %+v", problems)
+			}
+			if strings.Contains(errs[0].Message, os.TempDir()) {
+				t.Errorf("message should not name the scratch file: %q", errs[0].Message)
+			}
+		})
+	}
+}
+
+func TestCheckFlagsShadowing(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "deploy"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	problems := lint("bash", map[string]Shortcut{
+		"deploy": {Command: "./deploy.sh"},
+		"cd":     {Command: `builtin cd "$1"`},
+		"open":   {Command: "xdg-open $1"},
+		"my-gs":  {Command: "git status"},
+	})
+	want := map[string]string{
+		"deploy": "shadows " + filepath.Join(bin, "deploy") + " on PATH",
+		"cd":     "shadows a shell builtin",
+		"open":   "unquoted $1",
+		"my-gs":  "not a valid shell identifier",
+	}
+	for name, msg := range want {
+		found := false
+		for _, p := range problems {
+			if p.Shortcut == name && strings.HasPrefix(p.Message, msg) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no %q problem for %s in %+v", msg, name, problems)
+		}
+	}
+}
+
+func TestSyntaxCommand(t *testing.T) {
+	tests := []struct {
+		terminal, bin string
+	}{
+		{"bash", "bash"},
+		{"zsh", "zsh"},
+		{"fish", "fish"},
+		{"dash", "dash"},
+		{"sh", "sh"},
+		{"nu", "nu"},
+		{"tcsh", "bash"},
+	}
+	for _, tt := range tests {
+		if bin, _ := syntaxCommand(tt.terminal, "/f"); bin != tt.bin {
+			t.Errorf("syntaxCommand(%q) = %s, want %s", tt.terminal, bin, tt.bin)
+		}
+	}
+}
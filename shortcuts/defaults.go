@@ -4,9 +4,10 @@ package shortcuts
 
 // ShortcutDef holds everything dolly knows about one built-in shortcut.
 type ShortcutDef struct {
-	Command     string // shell command template ($1, $2 for positional args)
-	Description string // one-line human description shown in docs
-	Example     string // ready-to-run invocation shown in docs (no $ prefix)
+	Command     string            // shell command template ($1, $2 for positional args)
+	Description string            // one-line human description shown in docs
+	Example     string            // ready-to-run invocation shown in docs (no $ prefix)
+	Variants    map[string]string // per-shell commands where Command can't be translated, keyed by shell ("fish")
 }
 
 // ShortcutGroup holds shortcuts that share the same root CLI tool.
@@ -63,7 +64,7 @@ var DefaultShortcutGroups = map[string]ShortcutGroup{
 			Example:     `fnew go.mod`,
 		},
 		"fsize": {
-			Command:     `find . -type f -size +${1:-10}M`,
+			Command:     `find . -type f -size +"${1:-10}"M`,
 			Description: "Find files larger than SIZE MB (default: 10 MB)",
 			Example:     `fsize 50`,
 		},
@@ -73,11 +74,19 @@ var DefaultShortcutGroups = map[string]ShortcutGroup{
 			Command:     `pane=$(tmux split-window -h -P -F "#{pane_id}") && tmux send-keys -t "$pane" "source $DOLLY_SHORTCUTS_FILE" Enter`,
 			Description: "Split current pane — new pane opens to the right; shortcuts auto-sourced",
 			Example:     `vsp`,
+			Variants: map[string]string{
+				ShellFish: `set -l pane (tmux split-window -h -P -F "#{pane_id}"); and tmux send-keys -t "$pane" "source $DOLLY_SHORTCUTS_FILE" Enter`,
+				ShellSh:   `pane=$(tmux split-window -h -P -F "#{pane_id}") && tmux send-keys -t "$pane" ". $DOLLY_SHORTCUTS_FILE" Enter`,
+			},
 		},
 		"sp": {
 			Command:     `pane=$(tmux split-window -v -P -F "#{pane_id}") && tmux send-keys -t "$pane" "source $DOLLY_SHORTCUTS_FILE" Enter`,
 			Description: "Split current pane — new pane opens below; shortcuts auto-sourced",
 			Example:     `sp`,
+			Variants: map[string]string{
+				ShellFish: `set -l pane (tmux split-window -v -P -F "#{pane_id}"); and tmux send-keys -t "$pane" "source $DOLLY_SHORTCUTS_FILE" Enter`,
+				ShellSh:   `pane=$(tmux split-window -v -P -F "#{pane_id}") && tmux send-keys -t "$pane" ". $DOLLY_SHORTCUTS_FILE" Enter`,
+			},
 		},
 		"zoom": {
 			Command:     `tmux resize-pane -Z`,
@@ -88,6 +97,10 @@ var DefaultShortcutGroups = map[string]ShortcutGroup{
 			Command:     `pane=$(tmux new-window -P -F "#{pane_id}") && tmux send-keys -t "$pane" "source $DOLLY_SHORTCUTS_FILE" Enter`,
 			Description: "Open a new window in the current session; shortcuts auto-sourced",
 			Example:     `nw`,
+			Variants: map[string]string{
+				ShellFish: `set -l pane (tmux new-window -P -F "#{pane_id}"); and tmux send-keys -t "$pane" "source $DOLLY_SHORTCUTS_FILE" Enter`,
+				ShellSh:   `pane=$(tmux new-window -P -F "#{pane_id}") && tmux send-keys -t "$pane" ". $DOLLY_SHORTCUTS_FILE" Enter`,
+			},
 		},
 		"kp": {
 			Command:     `tmux kill-pane`,
@@ -102,10 +115,24 @@ var DefaultShortcutGroups = map[string]ShortcutGroup{
 	}},
 }
 
-// DefaultShortcuts is the flattened command-only map used by Merge and
-// WriteShellFile. No callers outside this package need to change.
+// DefaultShortcuts is the flattened command-only map of the built-ins.
 var DefaultShortcuts = flattenGroups(DefaultShortcutGroups)
 
+// Defaults is the built-in layer passed to Merge. Unlike DefaultShortcuts it
+// keeps each description and example, so generated functions can print them
+// for -h.
+var Defaults = defaultLayer(DefaultShortcutGroups)
+
+func defaultLayer(groups map[string]ShortcutGroup) map[string]Shortcut {
+	layer := make(map[string]Shortcut)
+	for _, g := range groups {
+		for name, def := range g.Shortcuts {
+			layer[name] = Shortcut{Command: def.Command, Description: def.Description, Example: def.Example}
+		}
+	}
+	return layer
+}
+
 func flattenGroups(groups map[string]ShortcutGroup) map[string]string {
 	flat := make(map[string]string)
 	for _, g := range groups {
@@ -0,0 +1,144 @@
+package shortcuts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultVariants maps a built-in shortcut name to its per-shell commands.
+var defaultVariants = collectVariants(DefaultShortcutGroups)
+
+func collectVariants(groups map[string]ShortcutGroup) map[string]map[string]string {
+	variants := make(map[string]map[string]string)
+	for _, g := range groups {
+		for name, def := range g.Shortcuts {
+			if len(def.Variants) > 0 {
+				variants[name] = def.Variants
+			}
+		}
+	}
+	return variants
+}
+
+// CommandFor returns the body to write for a shortcut in the given shell. A
+// built-in that the user has not overridden uses its hand-written variant
+// for that shell; anything else targeting fish goes through ToFish.
+func CommandFor(shell, name, command string) string {
+	if v, ok := defaultVariants[name][shell]; ok && command == DefaultShortcuts[name] {
+		return v
+	}
+	if shell == ShellFish {
+		return ToFish(command)
+	}
+	return command
+}
+
+// ToFish rewrites the POSIX positional-argument syntax used in shortcut
+// commands into fish: $1 and ${1} become $argv[1], $@ and $* become $argv
+// ("$@" unquoted, so each argument stays separate; "$*" stays joined),
+// $# becomes (count $argv), and ${1:-10} becomes a local variable set from
+// argv when given and non-empty. Text inside single quotes and escaped
+// dollars are left alone. Other bash-only syntax is not translated; built-ins
+// that need it carry a fish variant instead.
+func ToFish(command string) string {
+	var out strings.Builder
+	var prelude []string
+	defaulted := map[string]bool{}
+	inSingle, inDouble := false, false
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == '\\' && !inSingle && i+1 < len(command):
+			out.WriteByte(c)
+			out.WriteByte(command[i+1])
+			i++
+			continue
+		// Quoting a list in fish joins it into one argument, so "$@" becomes
+		// a bare $argv to keep each argument separate. Inside a longer
+		// string the quotes are closed around it.
+		case c == '"' && !inSingle && !inDouble && strings.HasPrefix(command[i:], `"$@"`):
+			out.WriteString("$argv")
+			i += 3
+			if i+1 < len(command) && isNameByte(command[i+1]) {
+				out.WriteString(`""`) // "$@"x must not read as $argvx
+			}
+			continue
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		case c == '$' && inDouble && strings.HasPrefix(command[i:], "$@"):
+			out.WriteString(`"$argv"`)
+			i++
+			continue
+		case c == '$' && !inSingle && i+1 < len(command):
+			if repl, n, pre := translateParam(command[i+1:], defaulted); n > 0 {
+				out.WriteString(repl)
+				if pre != "" {
+					prelude = append(prelude, pre)
+				}
+				i += n
+				continue
+			}
+		}
+		out.WriteByte(c)
+	}
+
+	if len(prelude) == 0 {
+		return out.String()
+	}
+	return strings.Join(prelude, "\n") + "\n" + out.String()
+}
+
+// translateParam translates the parameter reference that follows a '$'. It
+// returns the fish replacement, how many bytes after the '$' it consumed (0
+// when the reference is not positional), and a prelude line for defaults.
+func translateParam(rest string, defaulted map[string]bool) (repl string, n int, prelude string) {
+	switch c := rest[0]; {
+	case c >= '1' && c <= '9':
+		return "$argv[" + string(c) + "]", 1, ""
+	case c == '@' || c == '*':
+		return "$argv", 1, ""
+	case c == '#':
+		return "(count $argv)", 1, ""
+	case c != '{':
+		return "", 0, ""
+	}
+
+	end := strings.IndexByte(rest, '}')
+	if end < 0 {
+		return "", 0, ""
+	}
+	inner := rest[1:end]
+	digits := 0
+	for digits < len(inner) && inner[digits] >= '0' && inner[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 {
+		return "", 0, ""
+	}
+	index := inner[:digits]
+	switch {
+	case digits == len(inner):
+		return "$argv[" + index + "]", end + 1, ""
+	case strings.HasPrefix(inner[digits:], ":-"):
+		def := inner[digits+2:]
+		// The [1] index ends the variable name, so text that follows the
+		// reference (${1:-10}M) is not read as part of it.
+		variable := "arg" + index
+		ref := "$" + variable + "[1]"
+		if defaulted[index] {
+			return ref, end + 1, ""
+		}
+		defaulted[index] = true
+		return ref, end + 1, fmt.Sprintf(
+			"set -l %s %s\nset -q argv[%s]; and test -n \"$argv[%s]\"; and set %s $argv[%s]",
+			variable, def, index, index, variable, index)
+	}
+	return "", 0, ""
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || c == '[' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
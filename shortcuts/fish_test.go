@@ -0,0 +1,99 @@
+package shortcuts
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestToFish(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`grep -rn "$1" .`, `grep -rn "$argv[1]" .`},
+		{`cp $1 ${2}`, `cp $argv[1] $argv[2]`},
+		{`echo "$@" $*`, `echo $argv $argv`},
+		{`echo "$*"`, `echo "$argv"`},
+		{`grep -rn "$@" .`, `grep -rn $argv .`},
+		{`echo "args: $@"`, `echo "args: "$argv""`},
+		{`touch "$@"_bak`, `touch $argv""_bak`},
+		{`echo $#`, `echo (count $argv)`},
+		{`git status`, `git status`},
+		{`echo $HOME ${PATH}`, `echo $HOME ${PATH}`},
+		{`echo '$1' \$1`, `echo '$1' \$1`},
+		{`echo "it's $1"`, `echo "it's $argv[1]"`},
+		{
+			`find . -type f -size +${1:-10}M`,
+			"set -l arg1 10\nset -q argv[1]; and test -n \"$argv[1]\"; and set arg1 $argv[1]\nfind . -type f -size +$arg1[1]M",
+		},
+	}
+	for _, tt := range tests {
+		if got := ToFish(tt.in); got != tt.want {
+			t.Errorf("ToFish(%q) =\n%s\nwant\n%s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestToFishRepeatedDefault(t *testing.T) {
+	got := ToFish(`echo ${1:-x} ${1:-x}`)
+	if strings.Count(got, "set -l arg1") != 1 {
+		t.Errorf("default declared more than once:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "echo $arg1[1] $arg1[1]") {
+		t.Errorf("unexpected body:\n%s", got)
+	}
+}
+
+func TestCommandFor(t *testing.T) {
+	// Built-ins with a fish variant use it
+	if got := CommandFor(ShellFish, "vsp", DefaultShortcuts["vsp"]); !strings.HasPrefix(got, "set -l pane (") {
+		t.Errorf("expected fish variant for vsp, got %q", got)
+	}
+	// A user override of a built-in is translated, not replaced by the variant
+	if got := CommandFor(ShellFish, "vsp", `tmux split-window -h "$1"`); got != `tmux split-window -h "$argv[1]"` {
+		t.Errorf("override: got %q", got)
+	}
+	// Other shells get the command unchanged
+	if got := CommandFor("bash", "search", DefaultShortcuts["search"]); got != DefaultShortcuts["search"] {
+		t.Errorf("bash: got %q", got)
+	}
+}
+
+// checkSyntax writes every built-in shortcut for terminal and runs the
+// shell's no-exec syntax check on the result.
+func checkSyntax(t *testing.T, shell, terminal string) {
+	t.Helper()
+	bin, err := exec.LookPath(shell)
+	if err != nil {
+		t.Skipf("%s not installed", shell)
+	}
+	t.Setenv("HOME", t.TempDir())
+	user := map[string]Shortcut{
+		"sized": {Command: `du -sh ${1:-.}`},
+		"both":  {Command: `diff "$1" "$2"`, Description: "Compare two files", Args: []Arg{{Name: "a"}, {Name: "b", Default: "-"}}},
+		"quote": {Command: `echo "it's"`, Description: `Prints "it's" \ done`},
+	}
+	sc := Merge(Defaults, user, nil, nil)
+	path, err := WriteShellFile("syntax", terminal, sc)
+	if err != nil {
+		t.Fatalf("WriteShellFile: %v", err)
+	}
+	if out, err := exec.Command(bin, "-n", path).CombinedOutput(); err != nil {
+		t.Errorf("%s -n %s: %v\n%s", shell, path, err, out)
+	}
+}
+
+func TestFishFileSyntax(t *testing.T) { checkSyntax(t, "fish", "fish") }
+
+func TestBashFileSyntax(t *testing.T) { checkSyntax(t, "bash", "bash") }
+
+func TestFishFileHasNoPositionalSyntax(t *testing.T) {
+	for name, cmd := range DefaultShortcuts {
+		body := CommandFor(ShellFish, name, cmd)
+		for _, bad := range []string{"$1", "${1", "$(", "=$("} {
+			if strings.Contains(body, bad) {
+				t.Errorf("fish body for %s still contains %q:\n%s", name, bad, body)
+			}
+		}
+	}
+}
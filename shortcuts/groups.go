@@ -0,0 +1,154 @@
+package shortcuts
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GroupFilter selects shortcut groups for a session. Groups are the built-in
+// DefaultShortcutGroups, any defined under groups: in the global file, and
+// the shortcut packs by namespace.
+type GroupFilter struct {
+	Include []string // only these groups; every group when empty
+	Exclude []string // never these groups
+}
+
+// Allows reports whether shortcuts from group reach the session.
+func (f GroupFilter) Allows(group string) bool {
+	for _, g := range f.Exclude {
+		if g == group {
+			return false
+		}
+	}
+	if len(f.Include) == 0 {
+		return true
+	}
+	for _, g := range f.Include {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// Unknown returns the names in the filter that match neither a built-in
+// group nor one of the user's groups or packs, so a typo is reported instead
+// of silently selecting nothing.
+func (f GroupFilter) Unknown(user ...map[string]map[string]Shortcut) []string {
+	var unknown []string
+	seen := map[string]bool{}
+	for _, list := range [][]string{f.Include, f.Exclude} {
+		for _, g := range list {
+			_, builtin := DefaultShortcutGroups[g]
+			own := false
+			for _, groups := range user {
+				if _, ok := groups[g]; ok {
+					own = true
+				}
+			}
+			if !builtin && !own && !seen[g] {
+				unknown = append(unknown, g)
+				seen[g] = true
+			}
+		}
+	}
+	return unknown
+}
+
+// SelectDefaults returns the built-in shortcuts of the groups f allows.
+func SelectDefaults(f GroupFilter) map[string]Shortcut {
+	layer := make(map[string]Shortcut)
+	for name, g := range DefaultShortcutGroups {
+		if !f.Allows(name) {
+			continue
+		}
+		for sc := range g.Shortcuts {
+			layer[sc] = Defaults[sc]
+		}
+	}
+	return layer
+}
+
+// LoadGroups reads the user-defined groups from ~/.dolly/shortcuts.yml.
+// Returns an empty map (not an error) if the file does not exist.
+func LoadGroups() (map[string]map[string]Shortcut, error) {
+	f, err := loadGlobalFile()
+	if err != nil {
+		return map[string]map[string]Shortcut{}, err
+	}
+	if f.Groups == nil {
+		return map[string]map[string]Shortcut{}, nil
+	}
+	return f.Groups, nil
+}
+
+// LoadGlobalLayer returns the global layer as a session sees it: the
+// ungrouped shortcuts plus those of every user group and pack (from
+// LoadPacks) f allows. Ungrouped shortcuts win a name clash, then groups,
+// then packs; between groups or packs the first by name wins.
+func LoadGlobalLayer(f GroupFilter, packs map[string]map[string]Shortcut) (map[string]Shortcut, error) {
+	file, err := loadGlobalFile()
+	if err != nil {
+		return map[string]Shortcut{}, err
+	}
+	layer := make(map[string]Shortcut)
+
+	for name, sc := range file.Shortcuts {
+		layer[name] = sc
+	}
+	for _, groups := range []map[string]map[string]Shortcut{file.Groups, packs} {
+		for _, g := range sortedGroups(groups) {
+			if !f.Allows(g) {
+				continue
+			}
+			for name, sc := range groups[g] {
+				if _, taken := layer[name]; !taken {
+					layer[name] = sc
+				}
+			}
+		}
+	}
+	return layer, nil
+}
+
+// UserGroupOf returns the user group that defines name, or "" if none does.
+func UserGroupOf(groups map[string]map[string]Shortcut, name string) string {
+	for _, g := range sortedGroups(groups) {
+		if _, ok := groups[g][name]; ok {
+			return g
+		}
+	}
+	return ""
+}
+
+func sortedGroups(groups map[string]map[string]Shortcut) []string {
+	names := make([]string, 0, len(groups))
+	for g := range groups {
+		names = append(names, g)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadGlobalFile parses ~/.dolly/shortcuts.yml. A missing file is empty.
+func loadGlobalFile() (*shortcutsFile, error) {
+	path, err := globalFilePath()
+	if err != nil {
+		return &shortcutsFile{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &shortcutsFile{}, nil
+		}
+		return &shortcutsFile{}, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	var f shortcutsFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return &shortcutsFile{}, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	return &f, nil
+}
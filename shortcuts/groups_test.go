@@ -0,0 +1,173 @@
+package shortcuts
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const groupedGlobal = `# team helpers
+shortcuts:
+  deploy: ./deploy.sh
+  kp: echo ungrouped wins
+groups:
+  k8s:
+    kp: kubectl get pods
+    kl: kubectl logs -f "$1"
+  infra:
+    tf: terraform plan
+`
+
+func TestGroupFilterAllows(t *testing.T) {
+	tests := []struct {
+		f     GroupFilter
+		group string
+		want  bool
+	}{
+		{GroupFilter{}, "grep", true},
+		{GroupFilter{Include: []string{"tmux"}}, "tmux", true},
+		{GroupFilter{Include: []string{"tmux"}}, "grep", false},
+		{GroupFilter{Exclude: []string{"find"}}, "find", false},
+		{GroupFilter{Exclude: []string{"find"}}, "grep", true},
+		{GroupFilter{Include: []string{"tmux", "find"}, Exclude: []string{"find"}}, "find", false},
+	}
+	for _, tt := range tests {
+		if got := tt.f.Allows(tt.group); got != tt.want {
+			t.Errorf("%+v.Allows(%q) = %v, want %v", tt.f, tt.group, got, tt.want)
+		}
+	}
+}
+
+func TestGroupFilterUnknown(t *testing.T) {
+	user := map[string]map[string]Shortcut{"k8s": {}}
+	f := GroupFilter{Include: []string{"tmux", "k8s", "tmxu"}, Exclude: []string{"grpe", "tmxu"}}
+	if got, want := f.Unknown(user), []string{"tmxu", "grpe"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unknown = %v, want %v", got, want)
+	}
+}
+
+func TestSelectDefaults(t *testing.T) {
+	only := SelectDefaults(GroupFilter{Include: []string{"tmux"}})
+	if _, ok := only["vsp"]; !ok {
+		t.Error("tmux group should include vsp")
+	}
+	if _, ok := only["search"]; ok {
+		t.Error("grep shortcuts should be excluded")
+	}
+	if only["vsp"].Description == "" {
+		t.Error("selected built-ins should keep their description")
+	}
+
+	without := SelectDefaults(GroupFilter{Exclude: []string{"grep"}})
+	if _, ok := without["search"]; ok {
+		t.Error("excluded grep group still present")
+	}
+	if len(without) != len(DefaultShortcuts)-len(DefaultShortcutGroups["grep"].Shortcuts) {
+		t.Errorf("got %d shortcuts after excluding grep", len(without))
+	}
+}
+
+func TestLoadGlobalLayer(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeShortcutsFile(t, home, groupedGlobal)
+
+	all, err := LoadGlobalLayer(GroupFilter{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"deploy", "kp", "kl", "tf"} {
+		if _, ok := all[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+	}
+	if all["kp"].Command != "echo ungrouped wins" {
+		t.Errorf("ungrouped shortcut should win a clash, got %q", all["kp"].Command)
+	}
+
+	infra, err := LoadGlobalLayer(GroupFilter{Include: []string{"infra"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := infra["kl"]; ok {
+		t.Error("k8s group should not be selected")
+	}
+	if _, ok := infra["deploy"]; !ok {
+		t.Error("ungrouped shortcuts are always included")
+	}
+
+	// LoadGlobal still returns only the ungrouped shortcuts
+	plain, err := LoadGlobal()
+	if err != nil || len(plain) != 2 {
+		t.Errorf("LoadGlobal = %v, %v", plain, err)
+	}
+	groups, err := LoadGroups()
+	if err != nil || UserGroupOf(groups, "tf") != "infra" || UserGroupOf(groups, "deploy") != "" {
+		t.Errorf("LoadGroups = %v, %v", groups, err)
+	}
+}
+
+func TestSaveGlobalKeepsGroups(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := writeShortcutsFile(t, home, groupedGlobal)
+
+	if _, err := AddGlobal("gs", "git status"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	for _, want := range []string{"# team helpers", "gs: git status", "groups:", "tf: terraform plan"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("saved file lost %q:\n%s", want, content)
+		}
+	}
+	groups, err := LoadGroups()
+	if err != nil || len(groups["k8s"]) != 2 {
+		t.Errorf("groups after save = %v, %v", groups, err)
+	}
+}
+
+func TestRemoveGlobalGrouped(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := writeShortcutsFile(t, home, groupedGlobal)
+
+	// kp is both ungrouped and in k8s; the ungrouped one goes first.
+	if group, err := RemoveGlobal("kp"); err != nil || group != "" {
+		t.Fatalf("RemoveGlobal(kp) = %q, %v; want ungrouped removal", group, err)
+	}
+	if group, err := RemoveGlobal("kp"); err != nil || group != "k8s" {
+		t.Fatalf("RemoveGlobal(kp) again = %q, %v; want k8s", group, err)
+	}
+	if group, err := RemoveGlobal("tf"); err != nil || group != "infra" {
+		t.Fatalf("RemoveGlobal(tf) = %q, %v; want infra", group, err)
+	}
+
+	groups, err := LoadGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := groups["k8s"]["kp"]; ok || len(groups["k8s"]) != 1 {
+		t.Errorf("k8s after remove = %v", groups["k8s"])
+	}
+	if len(groups["infra"]) != 0 {
+		t.Errorf("infra after remove = %v", groups["infra"])
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# team helpers", "deploy: ./deploy.sh", "kl: kubectl logs"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("saved file lost %q:\n%s", want, data)
+		}
+	}
+	if _, err := RemoveGlobal("tf"); err == nil {
+		t.Error("expected error removing tf twice")
+	}
+}
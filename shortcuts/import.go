@@ -0,0 +1,304 @@
+package shortcuts
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Candidate is an alias or function found by ParseRC. Skip explains why it
+// cannot be imported; it is empty for one that can.
+type Candidate struct {
+	Name    string
+	Command string
+	Kind    string // "alias" or "function"
+	Line    int
+	Skip    string
+	Warning string // e.g. the name shadows a shell builtin
+}
+
+var (
+	// name() {   function name {   function name() {
+	funcStart = regexp.MustCompile(`^(?:function\s+([A-Za-z_][\w.:-]*)\s*(?:\(\))?|([A-Za-z_][\w.:-]*)\s*\(\))\s*(\{.*)?$`)
+	// function name [options], fish style, ended by `end`
+	fishFuncStart = regexp.MustCompile(`^function\s+(\S+)`)
+	assignment    = regexp.MustCompile(`^[A-Za-z_][\w.:-]*=`)
+)
+
+// ParseRC finds the aliases and simple functions in a shell rc file or in
+// the output of `alias`. bash and fish print `alias` lines like an rc file;
+// zsh prints bare name=value lines, which are read as aliases only when the
+// whole input has that shape. Functions are read up to their closing brace;
+// fish functions are reported but skipped, since shortcuts use POSIX syntax.
+func ParseRC(r io.Reader) ([]Candidate, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	bare := isAliasOutput(lines)
+
+	var found []Candidate
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case bare:
+			found = append(found, parseAlias(line, i+1)...)
+		case strings.HasPrefix(line, "alias "):
+			found = append(found, parseAlias(strings.TrimPrefix(line, "alias "), i+1)...)
+		case isFishFunction(lines, i):
+			name := fishFuncStart.FindStringSubmatch(line)[1]
+			found = append(found, Candidate{Name: name, Kind: "function", Line: i + 1,
+				Skip: "fish function; rewrite it in POSIX syntax"})
+		case funcStart.MatchString(line):
+			c, end := parseFunction(lines, i)
+			found = append(found, c)
+			i = end
+		}
+	}
+	return validate(found), nil
+}
+
+// isFishFunction reports whether lines[i] starts a fish function: `function
+// name` with neither parentheses nor a brace on it or the next line.
+func isFishFunction(lines []string, i int) bool {
+	line := strings.TrimSpace(lines[i])
+	if !fishFuncStart.MatchString(line) || strings.ContainsAny(line, "(){") {
+		return false
+	}
+	return i+1 >= len(lines) || strings.TrimSpace(lines[i+1]) != "{"
+}
+
+// isAliasOutput reports whether every non-blank line is a bare name=value
+// assignment, which is how zsh prints `alias`.
+func isAliasOutput(lines []string) bool {
+	seen := false
+	for _, l := range lines {
+		if l = strings.TrimSpace(l); l == "" {
+			continue
+		}
+		if !assignment.MatchString(l) {
+			return false
+		}
+		seen = true
+	}
+	return seen
+}
+
+// parseAlias parses what follows `alias`: one or more name=value words in
+// bash and zsh, or `name value` in fish.
+func parseAlias(rest string, line int) []Candidate {
+	words, ok := splitWords(rest)
+	if !ok {
+		return []Candidate{{Kind: "alias", Line: line, Name: aliasName(rest), Skip: "unterminated quote"}}
+	}
+	if len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		return []Candidate{{Kind: "alias", Line: line, Name: aliasName(strings.Join(words[1:], " ")),
+			Skip: "alias option " + words[0] + " is not supported"}}
+	}
+	if len(words) == 2 && !strings.Contains(words[0], "=") {
+		return []Candidate{{Name: words[0], Command: aliasCommand(words[0], words[1]), Kind: "alias", Line: line}}
+	}
+	var found []Candidate
+	for _, w := range words {
+		name, value, ok := strings.Cut(w, "=")
+		if !ok {
+			continue // `alias name` only prints the alias
+		}
+		found = append(found, Candidate{Name: name, Command: aliasCommand(name, value), Kind: "alias", Line: line})
+	}
+	return found
+}
+
+func aliasName(s string) string {
+	name, _, _ := strings.Cut(s, "=")
+	return firstWord(name)
+}
+
+func firstWord(s string) string {
+	if f := strings.Fields(s); len(f) > 0 {
+		return f[0]
+	}
+	return ""
+}
+
+// aliasCommand turns an alias value into a function body. An alias gets its
+// arguments appended, so the body passes "$@" on. An alias that wraps the
+// command of the same name calls it with `command` so the function does not
+// call itself.
+func aliasCommand(name, value string) string {
+	value = strings.TrimSpace(value)
+	if firstWord(value) == name {
+		value = "command " + value
+	}
+	return value + ` "$@"`
+}
+
+// parseFunction reads the function starting at lines[start] and returns it
+// with the index of its last line.
+func parseFunction(lines []string, start int) (Candidate, int) {
+	m := funcStart.FindStringSubmatch(strings.TrimSpace(lines[start]))
+	c := Candidate{Name: m[1] + m[2], Kind: "function", Line: start + 1}
+	open := m[3]
+
+	i := start
+	if open == "" {
+		// Brace on the next line
+		if i+1 >= len(lines) || strings.TrimSpace(lines[i+1]) != "{" {
+			c.Skip = "not a simple function"
+			return c, i
+		}
+		i++
+		open = "{"
+	}
+
+	first := strings.TrimSpace(strings.TrimPrefix(open, "{"))
+	if strings.HasSuffix(first, "}") {
+		// name() { body; }
+		body := strings.TrimSpace(strings.TrimSuffix(first, "}"))
+		c.Command = strings.TrimSpace(strings.TrimSuffix(body, ";"))
+		if c.Command == "" {
+			c.Skip = "empty function"
+		}
+		return c, i
+	}
+
+	var body []string
+	if first != "" {
+		body = append(body, first)
+	}
+	depth := 1
+	for i++; i < len(lines); i++ {
+		t := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(t, "}") {
+			depth--
+			if depth == 0 {
+				if t != "}" && t != "};" {
+					c.Skip = "not a simple function"
+				}
+				break
+			}
+		}
+		if strings.HasSuffix(t, "{") {
+			depth++
+		}
+		body = append(body, lines[i])
+	}
+	switch {
+	case i == len(lines):
+		c.Skip = "no closing brace"
+	case c.Skip == "" && len(body) == 0:
+		c.Skip = "empty function"
+	}
+	c.Command = dedent(body)
+	return c, i
+}
+
+// dedent removes the indentation common to all non-blank lines and any
+// blank lines at either end.
+func dedent(lines []string) string {
+	indent := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	out := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if len(l) >= indent && indent > 0 {
+			l = l[indent:]
+		}
+		out = append(out, strings.TrimRight(l, " \t"))
+	}
+	return strings.Trim(strings.Join(out, "\n"), "\n")
+}
+
+// validate checks names with ValidateName and skips every definition but
+// the last of a name, as the shell would.
+func validate(found []Candidate) []Candidate {
+	last := map[string]int{}
+	for i, c := range found {
+		last[c.Name] = i
+	}
+	for i := range found {
+		c := &found[i]
+		if c.Skip != "" {
+			continue
+		}
+		if j := last[c.Name]; j != i {
+			c.Skip = fmt.Sprintf("redefined on line %d", found[j].Line)
+			continue
+		}
+		warn, err := ValidateName(c.Name)
+		if err != nil {
+			c.Skip = "not a valid shortcut name (letters, digits, underscores)"
+			continue
+		}
+		if warn != "" {
+			c.Warning = "shadows a shell builtin"
+		}
+	}
+	return found
+}
+
+// splitWords splits s into words the way a POSIX shell would for a simple
+// command: quotes are removed and an unquoted # starts a comment. ok is
+// false when a quote is not closed.
+func splitWords(s string) (words []string, ok bool) {
+	var cur strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		case c == '#' && !inWord:
+			return words, true
+		case c == '\\' && i+1 < len(s):
+			cur.WriteByte(s[i+1])
+			i++
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, false
+			}
+			cur.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+					i++
+				}
+				cur.WriteByte(s[i])
+			}
+			if i == len(s) {
+				return nil, false
+			}
+			inWord = true
+		default:
+			cur.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, true
+}
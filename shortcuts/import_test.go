@@ -0,0 +1,178 @@
+package shortcuts
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+const sampleRC = `# ~/.zshrc
+export EDITOR=vim
+alias gs='git status'
+alias ll="ls -la" la=ls   # two at once
+alias ls='ls --color=auto'
+alias -g G='| grep'
+alias k8s-ctx='kubectl config current-context'
+alias broken='oops
+
+mkcd() {
+    mkdir -p "$1" && cd "$1"
+}
+
+function serve { python3 -m http.server "${1:-8000}"; }
+
+function big() {
+  if [ -n "$1" ]; then
+    du -sh "$1"
+  else
+    du -sh .
+  fi
+}
+
+gs() { git status -sb; }
+`
+
+func TestParseRC(t *testing.T) {
+	found, err := ParseRC(strings.NewReader(sampleRC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]Candidate{}
+	for _, c := range found {
+		if c.Skip == "" {
+			got[c.Name] = c
+		}
+	}
+	want := map[string]string{
+		"ll":    `ls -la "$@"`,
+		"la":    `ls "$@"`,
+		"ls":    `command ls --color=auto "$@"`,
+		"mkcd":  `mkdir -p "$1" && cd "$1"`,
+		"serve": `python3 -m http.server "${1:-8000}"`,
+		"big":   "if [ -n \"$1\" ]; then\n  du -sh \"$1\"\nelse\n  du -sh .\nfi",
+		"gs":    "git status -sb",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d importable, want %d: %+v", len(got), len(want), got)
+	}
+	for name, cmd := range want {
+		if got[name].Command != cmd {
+			t.Errorf("%s: got %q, want %q", name, got[name].Command, cmd)
+		}
+	}
+	if got["ls"].Warning == "" {
+		t.Error("ls should warn that it shadows a builtin")
+	}
+	if got["mkcd"].Kind != "function" || got["mkcd"].Line != 10 {
+		t.Errorf("mkcd: got %+v", got["mkcd"])
+	}
+
+	skipped := map[string]string{}
+	for _, c := range found {
+		if c.Skip != "" {
+			skipped[c.Name] = c.Skip
+		}
+	}
+	for name, reason := range map[string]string{
+		"G":       "alias option -g",
+		"k8s-ctx": "not a valid shortcut name",
+		"broken":  "unterminated quote",
+		"gs":      "redefined on line 24",
+	} {
+		if !strings.HasPrefix(skipped[name], reason) {
+			t.Errorf("%s: skip reason %q, want %q", name, skipped[name], reason)
+		}
+	}
+}
+
+func TestParseAliasOutput(t *testing.T) {
+	tests := []struct {
+		name, in string
+	}{
+		{"bash", "alias gs='git status'\nalias ll='ls -la'\n"},
+		{"zsh", "gs='git status'\nll='ls -la'\n"},
+		{"fish", "alias gs 'git status'\nalias ll 'ls -la'\n"},
+	}
+	for _, tt := range tests {
+		found, err := ParseRC(strings.NewReader(tt.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 2 || found[0].Command != `git status "$@"` || found[1].Name != "ll" {
+			t.Errorf("%s: got %+v", tt.name, found)
+		}
+	}
+}
+
+func TestParseRCSkipsFishFunctions(t *testing.T) {
+	found, err := ParseRC(strings.NewReader("function gco --description 'checkout'\n    git checkout $argv\nend\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Name != "gco" || !strings.HasPrefix(found[0].Skip, "fish function") {
+		t.Errorf("got %+v", found)
+	}
+}
+
+// TestImportedShortcutsRun checks that imported aliases still take their
+// arguments and that a self-wrapping alias does not recurse.
+func TestImportedShortcutsRun(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	found, err := ParseRC(strings.NewReader("alias say='echo said'\nalias echo='echo -n'\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := map[string]Shortcut{}
+	for _, c := range found {
+		sc[c.Name] = Shortcut{Command: c.Command}
+	}
+	path, err := WriteShellFile("import", "bash", sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(bash, "-c", ". "+path+"; say hello; echo x").CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if string(out) != "said hellox" { // say runs the echo shortcut too
+		t.Errorf("got %q", out)
+	}
+}
+
+// TestImportedAliasesInFish checks that an imported alias still passes each
+// argument separately once it is translated for fish.
+func TestImportedAliasesInFish(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	found, err := ParseRC(strings.NewReader(`alias show='printf "[%s]"'` + "\n"))
+	if err != nil || len(found) != 1 {
+		t.Fatalf("ParseRC = %+v, %v", found, err)
+	}
+	path, err := WriteShellFile("import", "fish", map[string]Shortcut{"show": {Command: found[0].Command}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `printf "[%s]" $argv`) {
+		t.Errorf("expected a bare $argv in the fish body:\n%s", data)
+	}
+
+	fish, err := exec.LookPath("fish")
+	if err != nil {
+		t.Skip("fish not installed")
+	}
+	out, err := exec.Command(fish, "--no-config", "-c", "source "+path+"; show 'a b' c").CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if string(out) != "[a b][c]" {
+		t.Errorf("got %q, want %q", out, "[a b][c]")
+	}
+}
@@ -0,0 +1,189 @@
+package shortcuts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// packFile is the YAML structure of a shortcut pack, a *.yml or *.yaml file
+// in one of the shortcut_sources directories:
+//
+//	namespace: ops
+//	shortcuts:
+//	  deploy: ./scripts/deploy.sh "$1"
+//
+// Its shortcuts are defined as NAMESPACE_NAME (ops_deploy), so packs from
+// different teams cannot collide with each other or with your own shortcuts.
+type packFile struct {
+	Namespace string              `yaml:"namespace,omitempty"`
+	Shortcuts map[string]Shortcut `yaml:"shortcuts"`
+}
+
+// Pack is one loaded pack file.
+type Pack struct {
+	Namespace string
+	Path      string
+	Shortcuts map[string]Shortcut // names already prefixed with the namespace
+}
+
+// LoadSources returns the shortcut_sources directories from
+// ~/.dolly/shortcuts.yml with ~ expanded.
+func LoadSources() ([]string, error) {
+	f, err := loadGlobalFile()
+	if err != nil {
+		return nil, err
+	}
+	home, _ := os.UserHomeDir()
+	dirs := make([]string, 0, len(f.Sources))
+	for _, dir := range f.Sources {
+		if dir == "~" || strings.HasPrefix(dir, "~/") {
+			dir = filepath.Join(home, strings.TrimPrefix(dir, "~"))
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// ReadPacks reads every *.yml and *.yaml pack at the top level of the
+// shortcut_sources directories, in the order the sources are listed. A
+// missing directory, a broken file or an invalid name does not stop the
+// others from loading; the problems are returned together as the error. A
+// pack whose namespace is also a built-in or user group is left out, since
+// shortcut_groups: could not tell the two apart.
+func ReadPacks() ([]Pack, error) {
+	dirs, err := LoadSources()
+	if err != nil {
+		return nil, err
+	}
+	groups, err := LoadGroups()
+	if err != nil {
+		return nil, err
+	}
+	var packs []Pack
+	var errs []error
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			errs = append(errs, fmt.Errorf("shortcut source %s: %w", dir, err))
+			continue
+		}
+		var files []string
+		for _, pattern := range []string{"*.yml", "*.yaml"} {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				errs = append(errs, err)
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+		for _, path := range files {
+			p, err := readPack(path)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			if _, builtin := DefaultShortcutGroups[p.Namespace]; builtin || groups[p.Namespace] != nil {
+				errs = append(errs, fmt.Errorf("%s: namespace %q is also a shortcut group; set a different namespace: in the pack", path, p.Namespace))
+				continue
+			}
+			if len(p.Shortcuts) > 0 {
+				packs = append(packs, p)
+			}
+		}
+	}
+	return packs, errors.Join(errs...)
+}
+
+// LoadPacks returns the shortcuts of every pack keyed by namespace, the
+// same shape as LoadGroups, so GroupFilter selects packs by namespace. When
+// two packs share a namespace the first source to define a name wins.
+func LoadPacks() (map[string]map[string]Shortcut, error) {
+	packs, err := ReadPacks()
+	byNamespace := make(map[string]map[string]Shortcut)
+	for _, p := range packs {
+		if byNamespace[p.Namespace] == nil {
+			byNamespace[p.Namespace] = make(map[string]Shortcut)
+		}
+		for name, sc := range p.Shortcuts {
+			if _, taken := byNamespace[p.Namespace][name]; !taken {
+				byNamespace[p.Namespace][name] = sc
+			}
+		}
+	}
+	return byNamespace, err
+}
+
+// readPack parses one pack file. Shortcuts whose prefixed name is not a
+// valid identifier are left out and reported in the error.
+func readPack(path string) (Pack, error) {
+	p := Pack{Path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	var f packFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return p, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	p.Namespace = f.Namespace
+	if p.Namespace == "" {
+		p.Namespace = namespaceFor(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	}
+	if !validName.MatchString(p.Namespace) {
+		return p, fmt.Errorf("%s: namespace %q must be a valid shell identifier", path, p.Namespace)
+	}
+
+	p.Shortcuts = make(map[string]Shortcut, len(f.Shortcuts))
+	var bad []string
+	for name, sc := range f.Shortcuts {
+		full := p.Namespace + "_" + name
+		if !validName.MatchString(full) {
+			bad = append(bad, name)
+			continue
+		}
+		p.Shortcuts[full] = sc
+	}
+	if len(bad) > 0 {
+		sort.Strings(bad)
+		return p, fmt.Errorf("%s: skipped invalid shortcut names: %s", path, strings.Join(bad, ", "))
+	}
+	return p, nil
+}
+
+// namespaceFor turns a file name such as ops-tools into a namespace
+// (ops_tools) by replacing the characters a shell name cannot contain.
+func namespaceFor(base string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, base)
+}
+
+// ExportPack writes shortcuts as a pack with the given namespace. Names
+// that already carry the namespace prefix are written without it, so a pack
+// can be re-exported from the shortcuts it defined.
+func ExportPack(path, namespace string, shortcuts map[string]Shortcut) error {
+	if !validName.MatchString(namespace) {
+		return fmt.Errorf("invalid pack name %q: must be a valid shell identifier (letters, digits, underscores; no hyphens)", namespace)
+	}
+	f := packFile{Namespace: namespace, Shortcuts: make(map[string]Shortcut, len(shortcuts))}
+	for name, sc := range shortcuts {
+		f.Shortcuts[strings.TrimPrefix(name, namespace+"_")] = sc
+	}
+	data, err := yaml.Marshal(&f)
+	if err != nil {
+		return fmt.Errorf("could not encode pack: %w", err)
+	}
+	header := fmt.Sprintf("# Shortcut pack %q. Shortcuts are defined as %s_NAME.\n"+
+		"# Add this file's directory to shortcut_sources: in ~/.dolly/shortcuts.yml.\n", namespace, namespace)
+	if err := os.WriteFile(path, append([]byte(header), data...), 0644); err != nil {
+		return fmt.Errorf("could not write %s: %w", path, err)
+	}
+	return nil
+}
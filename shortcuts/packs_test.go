@@ -0,0 +1,99 @@
+package shortcuts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePacks sets up a global file whose shortcut_sources lists a pack
+// directory with the given files, plus one directory that does not exist.
+func writePacks(t *testing.T, files map[string]string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, "platform-shortcuts")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeShortcutsFile(t, home, "shortcuts:\n  deploy: ./mine.sh\nshortcut_sources:\n  - ~/platform-shortcuts\n  - ~/gone\n")
+	return dir
+}
+
+func TestLoadPacks(t *testing.T) {
+	writePacks(t, map[string]string{
+		"ops.yml":         "namespace: ops\nshortcuts:\n  deploy: ./deploy.sh \"$1\"\n  bad-name: x\n",
+		"kube-tools.yaml": "shortcuts:\n  pods: kubectl get pods\n",
+		"grep.yml":        "shortcuts:\n  todo: grep -rn TODO .\n",
+		"README.md":       "not a pack",
+	})
+
+	packs, err := LoadPacks()
+	if err == nil || !strings.Contains(err.Error(), "bad-name") || !strings.Contains(err.Error(), "gone") {
+		t.Errorf("expected errors for the bad name and the missing source, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), `namespace "grep" is also a shortcut group`) {
+		t.Errorf("expected the grep pack to clash with the built-in group, got %v", err)
+	}
+	if packs["ops"]["ops_deploy"].Command != `./deploy.sh "$1"` {
+		t.Errorf("ops pack: got %+v", packs["ops"])
+	}
+	if _, ok := packs["kube_tools"]["kube_tools_pods"]; !ok {
+		t.Errorf("namespace should default to the file name: got %+v", packs)
+	}
+	if len(packs) != 2 {
+		t.Errorf("got %d namespaces, want 2", len(packs))
+	}
+}
+
+func TestLoadGlobalLayerWithPacks(t *testing.T) {
+	writePacks(t, map[string]string{"ops.yml": "shortcuts:\n  deploy: ./deploy.sh\n"})
+	packs, _ := LoadPacks()
+
+	layer, err := LoadGlobalLayer(GroupFilter{}, packs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layer["deploy"].Command != "./mine.sh" || layer["ops_deploy"].Command != "./deploy.sh" {
+		t.Errorf("pack should sit next to the user's own shortcut: %+v", layer)
+	}
+
+	without, err := LoadGlobalLayer(GroupFilter{Exclude: []string{"ops"}}, packs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := without["ops_deploy"]; ok {
+		t.Error("excluding the namespace should drop the pack")
+	}
+	if got := (GroupFilter{Include: []string{"ops"}}).Unknown(nil, packs); len(got) != 0 {
+		t.Errorf("a pack namespace is a known group, got %v", got)
+	}
+}
+
+func TestExportPackRoundTrip(t *testing.T) {
+	dir := writePacks(t, nil)
+	sc := map[string]Shortcut{
+		"deploy":    {Command: "./deploy.sh", Description: "Ship it"},
+		"ops_rollb": {Command: "./rollback.sh"},
+	}
+	if err := ExportPack(filepath.Join(dir, "ops.yml"), "ops", sc); err != nil {
+		t.Fatal(err)
+	}
+	packs, _ := LoadPacks()
+	if packs["ops"]["ops_deploy"].Description != "Ship it" {
+		t.Errorf("metadata lost: %+v", packs["ops"])
+	}
+	if _, ok := packs["ops"]["ops_rollb"]; !ok {
+		t.Errorf("prefixed name should not be prefixed twice: %+v", packs["ops"])
+	}
+
+	if err := ExportPack(filepath.Join(dir, "x.yml"), "ops-team", sc); err == nil {
+		t.Error("expected an error for an invalid pack name")
+	}
+}
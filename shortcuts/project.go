@@ -0,0 +1,128 @@
+package shortcuts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Layer names reported by Sources, from lowest to highest priority.
+const (
+	SourceDefault = "default"
+	SourceGlobal  = "global"
+	SourceProject = "project"
+	SourceSession = "session"
+)
+
+// projectFileName is the repository-relative location of project shortcuts.
+var projectFileName = filepath.Join(".dolly", "shortcuts.yml")
+
+// FindProjectFile walks up from dir and returns the nearest
+// .dolly/shortcuts.yml, or "" when there is none. The global
+// ~/.dolly/shortcuts.yml is not a project file and is skipped.
+func FindProjectFile(dir string) string {
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, strings.TrimPrefix(dir, "~"))
+		}
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	global, _ := globalFilePath()
+
+	for {
+		candidate := filepath.Join(dir, projectFileName)
+		if candidate != global {
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadProject reads the project layer for a working directory. It returns
+// the shortcuts and the file they came from; both are empty when no project
+// file exists. Project files arrive with cloned repositories, so one is only
+// loaded after `dolly shortcuts trust` recorded its current content (see
+// ErrUntrusted). A name that is not a valid identifier, or that shadows a
+// shell builtin or a command on PATH, rejects the file instead of only
+// warning as `dolly shortcuts add` does.
+func LoadProject(dir string) (map[string]Shortcut, string, error) {
+	path := FindProjectFile(dir)
+	if path == "" {
+		return map[string]Shortcut{}, "", nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return map[string]Shortcut{}, path, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	if !isTrusted(path, data) {
+		root := filepath.Dir(filepath.Dir(path))
+		return map[string]Shortcut{}, path, fmt.Errorf("%s: %w (new or changed); review it, then run `dolly shortcuts trust %s`", path, ErrUntrusted, root)
+	}
+	sc, err := parseProject(path, data)
+	if err != nil {
+		return map[string]Shortcut{}, path, err
+	}
+	return sc, path, nil
+}
+
+// parseProject parses and validates a project file.
+func parseProject(path string, data []byte) (map[string]Shortcut, error) {
+	var f shortcutsFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	for name := range f.Shortcuts {
+		warn, err := ValidateName(name)
+		if err == nil && warn != "" {
+			err = fmt.Errorf("project shortcut %q shadows a shell builtin", name)
+		}
+		if err == nil {
+			if bin, found := onPath(name); found {
+				err = fmt.Errorf("project shortcut %q shadows %s on PATH", name, bin)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if f.Shortcuts == nil {
+		return map[string]Shortcut{}, nil
+	}
+	return f.Shortcuts, nil
+}
+
+// Sources reports, for every name in the merged result, which layer its
+// command came from. Arguments are the same layers Merge takes.
+func Sources(defaults, global, project, session map[string]Shortcut) map[string]string {
+	sources := make(map[string]string)
+	for _, layer := range []struct {
+		name      string
+		shortcuts map[string]Shortcut
+	}{
+		{SourceDefault, defaults},
+		{SourceGlobal, global},
+		{SourceProject, project},
+		{SourceSession, session},
+	} {
+		for k := range layer.shortcuts {
+			sources[k] = layer.name
+		}
+	}
+	return sources
+}
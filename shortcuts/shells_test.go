@@ -0,0 +1,136 @@
+package shortcuts
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestWriteShellFileNu(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	path, err := WriteShellFile("nu-session", "nu", map[string]Shortcut{"greet": {Command: `echo "hi $1"`}})
+	if err != nil {
+		t.Fatalf("WriteShellFile: %v", err)
+	}
+	if !strings.HasSuffix(path, ".nu") {
+		t.Errorf("expected .nu extension, got %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+
+	for _, want := range []string{
+		`$env.DOLLY_SESSION = "nu-session"`,
+		`$env.DOLLY_SHORTCUTS_FILE = "` + path + `"`,
+		"def --wrapped greet [...args] {\n    ^sh -c r#'",
+		"\necho \"hi $1\"'# greet ...$args\n}",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("nu file missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "PROMPT_COMMAND") {
+		t.Error("nu file should not carry the sh reload hook")
+	}
+}
+
+func TestNuFunctionRawStringDelimiter(t *testing.T) {
+	got := nuFunction("q", "", `echo '#'`)
+	if !strings.Contains(got, `r##'echo '#''##`) {
+		t.Errorf("body containing '# needs a longer delimiter:\n%s", got)
+	}
+}
+
+func TestWriteShellFileSh(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for _, terminal := range []string{"sh", "dash"} {
+		path, err := WriteShellFile("posix", terminal, map[string]Shortcut{"gs": {Command: "git status"}})
+		if err != nil {
+			t.Fatalf("WriteShellFile(%s): %v", terminal, err)
+		}
+		data, _ := os.ReadFile(path)
+		content := string(data)
+		if !strings.HasSuffix(path, ".sh") {
+			t.Errorf("%s: expected .sh extension, got %s", terminal, path)
+		}
+		if !strings.Contains(content, "\ngs() {\n") || !strings.Contains(content, "\n    git status\n}") {
+			t.Errorf("%s: missing POSIX function syntax:\n%s", terminal, content)
+		}
+		if strings.Contains(content, "function ") {
+			t.Errorf("%s: POSIX file uses the function keyword:\n%s", terminal, content)
+		}
+	}
+}
+
+func TestDashFileSyntax(t *testing.T) { checkSyntax(t, "dash", "dash") }
+
+// TestShortcutsRunInDash sources a generated file in dash and calls shortcuts
+// with and without arguments.
+func TestShortcutsRunInDash(t *testing.T) {
+	dash, err := exec.LookPath("dash")
+	if err != nil {
+		t.Skip("dash not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	path, err := WriteShellFile("posix", "dash", map[string]Shortcut{
+		"greet": {Command: `echo "hi ${1:-there}"`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(dash, "-c", ". "+path+"; greet; greet bob; echo $DOLLY_SESSION").CombinedOutput()
+	if err != nil {
+		t.Fatalf("dash: %v\n%s", err, out)
+	}
+	if got, want := string(out), "hi there\nhi bob\nposix\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+// TestShortcutsRunInNu does the same for nushell, whose commands call out to sh.
+func TestShortcutsRunInNu(t *testing.T) {
+	nu, err := exec.LookPath("nu")
+	if err != nil {
+		t.Skip("nu not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	path, err := WriteShellFile("nu-session", "nu", map[string]Shortcut{"greet": {Command: `echo "hi $1"`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := exec.Command(nu, "-n", "-c", "source "+path+"; greet bob").CombinedOutput()
+	if err != nil {
+		t.Fatalf("nu: %v\n%s", err, out)
+	}
+	if got := strings.TrimSpace(string(out)); got != "hi bob" {
+		t.Errorf("output = %q, want %q", got, "hi bob")
+	}
+}
+
+func TestSourceCommand(t *testing.T) {
+	for terminal, want := range map[string]string{
+		"bash": "source /f",
+		"fish": "source /f",
+		"nu":   "source /f",
+		"sh":   ". /f",
+		"dash": ". /f",
+	} {
+		if got := SourceCommand(terminal, "/f"); got != want {
+			t.Errorf("SourceCommand(%q) = %q, want %q", terminal, got, want)
+		}
+	}
+}
+
+func TestCommandForSh(t *testing.T) {
+	got := CommandFor(ShellSh, "vsp", DefaultShortcuts["vsp"])
+	if strings.Contains(got, "source") || !strings.Contains(got, `". $DOLLY_SHORTCUTS_FILE"`) {
+		t.Errorf("sh variant of vsp should load the file with '.': %q", got)
+	}
+}
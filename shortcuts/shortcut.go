@@ -0,0 +1,207 @@
+package shortcuts
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Shortcut is one shortcut in a layer. In YAML it is either a bare command
+// string or a mapping that also documents it:
+//
+//	shortcuts:
+//	  gs: git status
+//	  big:
+//	    command: find "$1" -type f -size +"$2"M
+//	    description: List large files under a directory
+//	    example: big src 50
+//	    args:
+//	      - name: dir
+//	      - name: mb
+//	        default: "10"
+type Shortcut struct {
+	Command     string `yaml:"command"`
+	Description string `yaml:"description,omitempty"`
+	Example     string `yaml:"example,omitempty"`
+	Args        []Arg  `yaml:"args,omitempty"`
+}
+
+// Arg documents one positional argument. An argument with a default is
+// filled in when the caller omits it and every earlier argument was given.
+type Arg struct {
+	Name    string `yaml:"name"`
+	Default string `yaml:"default,omitempty"`
+}
+
+// UnmarshalYAML accepts both the bare-string and the mapping form.
+func (s *Shortcut) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = Shortcut{Command: node.Value}
+		return nil
+	}
+	type plain Shortcut
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	if p.Command == "" {
+		return fmt.Errorf("line %d: shortcut has no command", node.Line)
+	}
+	for i, a := range p.Args {
+		if a.Name == "" {
+			return fmt.Errorf("line %d: argument %d has no name", node.Line, i+1)
+		}
+	}
+	*s = Shortcut(p)
+	return nil
+}
+
+// MarshalYAML writes a shortcut without metadata as a bare string, so files
+// that never used the extended form keep their shape.
+func (s Shortcut) MarshalYAML() (interface{}, error) {
+	if s.Description == "" && s.Example == "" && len(s.Args) == 0 {
+		return s.Command, nil
+	}
+	type plain Shortcut
+	return plain(s), nil
+}
+
+// FromCommands wraps bare commands as shortcuts without metadata.
+func FromCommands(commands map[string]string) map[string]Shortcut {
+	out := make(map[string]Shortcut, len(commands))
+	for name, cmd := range commands {
+		out[name] = Shortcut{Command: cmd}
+	}
+	return out
+}
+
+var positionalRef = regexp.MustCompile(`\$\{?([1-9])`)
+
+// Usage returns the one-line synopsis, e.g. "big DIR [MB=10]". Without
+// declared args it lists ARG1..ARGn for the highest $n the command uses.
+func (s Shortcut) Usage(name string) string {
+	parts := []string{name}
+	if len(s.Args) > 0 {
+		for _, a := range s.Args {
+			if a.Default != "" {
+				parts = append(parts, fmt.Sprintf("[%s=%s]", strings.ToUpper(a.Name), a.Default))
+			} else {
+				parts = append(parts, strings.ToUpper(a.Name))
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+	highest := 0
+	for _, m := range positionalRef.FindAllStringSubmatch(s.Command, -1) {
+		if n := int(m[1][0] - '0'); n > highest {
+			highest = n
+		}
+	}
+	for i := 1; i <= highest; i++ {
+		parts = append(parts, fmt.Sprintf("ARG%d", i))
+	}
+	return strings.Join(parts, " ")
+}
+
+// helpLines is what a generated function prints for -h or --help.
+func (s Shortcut) helpLines(name string) []string {
+	lines := []string{"Usage: " + s.Usage(name)}
+	if s.Description != "" {
+		lines = append(lines, s.Description)
+	} else {
+		lines = append(lines, "Runs: "+strings.ReplaceAll(s.Command, "\n", "; "))
+	}
+	if s.Example != "" {
+		lines = append(lines, "Example: "+s.Example)
+	}
+	return lines
+}
+
+// shQuote single-quotes a string for POSIX shells.
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes a string for fish, where only \ and ' are special.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// forwardsArgs reports whether the command passes all of its arguments on
+// ("ls -la $@"). Such shortcuts leave -h to the wrapped command.
+func (s Shortcut) forwardsArgs() bool {
+	return strings.Contains(s.Command, "$@") || strings.Contains(s.Command, "$*")
+}
+
+// shPrelude is the start of a POSIX function body: -h/--help handling and
+// defaults for trailing arguments the caller left out. leave is "return"
+// inside a function and "exit" for a body run with sh -c.
+func (s Shortcut) shPrelude(name, leave string) []string {
+	var lines []string
+	if !s.forwardsArgs() {
+		quoted := make([]string, 0, 3)
+		for _, l := range s.helpLines(name) {
+			quoted = append(quoted, shQuote(l))
+		}
+		lines = append(lines, fmt.Sprintf(`case "$1" in -h|--help) printf '%%s\n' %s; %s 0 ;; esac`, strings.Join(quoted, " "), leave))
+	}
+	for i, a := range s.Args {
+		if a.Default != "" {
+			lines = append(lines, fmt.Sprintf(`[ $# -eq %d ] && set -- "$@" %s`, i, shQuote(a.Default)))
+		}
+	}
+	return lines
+}
+
+// fishPrelude is shPrelude for fish.
+func (s Shortcut) fishPrelude(name string) []string {
+	var lines []string
+	if !s.forwardsArgs() {
+		quoted := make([]string, 0, 3)
+		for _, l := range s.helpLines(name) {
+			quoted = append(quoted, fishQuote(l))
+		}
+		lines = append(lines,
+			"if contains -- \"$argv[1]\" -h --help",
+			"    printf '%s\\n' "+strings.Join(quoted, " "),
+			"    return 0",
+			"end")
+	}
+	for i, a := range s.Args {
+		if a.Default != "" {
+			lines = append(lines, fmt.Sprintf("test (count $argv) -eq %d; and set -a argv %s", i, fishQuote(a.Default)))
+		}
+	}
+	return lines
+}
+
+// Markdown renders shortcuts as a reference table in the same shape as the
+// generated docs/shortcuts.md.
+func Markdown(sc map[string]Shortcut) string {
+	names := make([]string, 0, len(sc))
+	for name := range sc {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("| Usage | Description | Example |\n")
+	b.WriteString("|-------|-------------|---------|\n")
+	for _, name := range names {
+		s := sc[name]
+		desc := s.Description
+		if desc == "" {
+			desc = "Runs `" + strings.ReplaceAll(s.Command, "\n", "; ") + "`"
+		}
+		example := ""
+		if s.Example != "" {
+			example = "`" + s.Example + "`"
+		}
+		cell := strings.NewReplacer("|", `\|`).Replace
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", s.Usage(name), cell(desc), cell(example))
+	}
+	return b.String()
+}
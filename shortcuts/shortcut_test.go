@@ -0,0 +1,171 @@
+package shortcuts
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const extendedYAML = `shortcuts:
+  gs: git status
+  big:
+    command: find "$1" -type f -size +"$2"M
+    description: List large files under a directory
+    example: big src 50
+    args:
+      - name: dir
+      - name: mb
+        default: "10"
+`
+
+func TestShortcutUnmarshalBothForms(t *testing.T) {
+	var f shortcutsFile
+	if err := yaml.Unmarshal([]byte(extendedYAML), &f); err != nil {
+		t.Fatal(err)
+	}
+	if gs := f.Shortcuts["gs"]; gs.Command != "git status" || gs.Description != "" || gs.Args != nil {
+		t.Errorf("bare form: got %+v", f.Shortcuts["gs"])
+	}
+	big := f.Shortcuts["big"]
+	if big.Command != `find "$1" -type f -size +"$2"M` || big.Description == "" || big.Example != "big src 50" {
+		t.Errorf("mapping form: got %+v", big)
+	}
+	if len(big.Args) != 2 || big.Args[1] != (Arg{Name: "mb", Default: "10"}) {
+		t.Errorf("args: got %+v", big.Args)
+	}
+}
+
+func TestShortcutUnmarshalErrors(t *testing.T) {
+	for _, doc := range []string{
+		"shortcuts:\n  x:\n    description: no command\n",
+		"shortcuts:\n  x:\n    command: echo\n    args:\n      - default: \"1\"\n",
+	} {
+		var f shortcutsFile
+		if err := yaml.Unmarshal([]byte(doc), &f); err == nil {
+			t.Errorf("%q: expected an error", doc)
+		}
+	}
+}
+
+func TestShortcutMarshalKeepsBareStrings(t *testing.T) {
+	var f shortcutsFile
+	if err := yaml.Unmarshal([]byte(extendedYAML), &f); err != nil {
+		t.Fatal(err)
+	}
+	out, err := yaml.Marshal(&f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "gs: git status\n") {
+		t.Errorf("plain shortcut should stay a bare string:\n%s", out)
+	}
+	var again shortcutsFile
+	if err := yaml.Unmarshal(out, &again); err != nil {
+		t.Fatal(err)
+	}
+	if len(again.Shortcuts["big"].Args) != 2 || again.Shortcuts["big"].Description != f.Shortcuts["big"].Description {
+		t.Errorf("round trip lost metadata: %+v", again.Shortcuts["big"])
+	}
+}
+
+func TestShortcutUsage(t *testing.T) {
+	tests := []struct {
+		sc   Shortcut
+		want string
+	}{
+		{Shortcut{Command: "git status"}, "gs"},
+		{Shortcut{Command: `grep -rn "$1" "${2:-.}"`}, "gs ARG1 ARG2"},
+		{Shortcut{Command: "x", Args: []Arg{{Name: "dir"}, {Name: "mb", Default: "10"}}}, "gs DIR [MB=10]"},
+	}
+	for _, tt := range tests {
+		if got := tt.sc.Usage("gs"); got != tt.want {
+			t.Errorf("Usage(%q) = %q, want %q", tt.sc.Command, got, tt.want)
+		}
+	}
+}
+
+// TestHelpAndDefaultsInPOSIXShells runs generated functions in every POSIX
+// shell that is installed.
+func TestHelpAndDefaultsInPOSIXShells(t *testing.T) {
+	var f shortcutsFile
+	if err := yaml.Unmarshal([]byte(extendedYAML), &f); err != nil {
+		t.Fatal(err)
+	}
+	f.Shortcuts["big"] = Shortcut{
+		Command:     `echo "dir=$1 mb=$2"`,
+		Description: f.Shortcuts["big"].Description,
+		Example:     f.Shortcuts["big"].Example,
+		Args:        f.Shortcuts["big"].Args,
+	}
+	f.Shortcuts["ll"] = Shortcut{Command: `echo ls "$@"`}
+
+	for _, shell := range []string{"bash", "dash"} {
+		bin, err := exec.LookPath(shell)
+		if err != nil {
+			continue
+		}
+		t.Run(shell, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			path, err := WriteShellFile("meta", shell, f.Shortcuts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			run := func(call string) string {
+				out, err := exec.Command(bin, "-c", ". "+path+"; "+call).CombinedOutput()
+				if err != nil {
+					t.Fatalf("%s: %v\n%s", call, err, out)
+				}
+				return string(out)
+			}
+
+			help := run("big --help")
+			for _, want := range []string{"Usage: big DIR [MB=10]\n", "List large files under a directory\n", "Example: big src 50\n"} {
+				if !strings.Contains(help, want) {
+					t.Errorf("big --help missing %q:\n%s", want, help)
+				}
+			}
+			if got := run("gs -h"); got != "Usage: gs\nRuns: git status\n" {
+				t.Errorf("gs -h = %q", got)
+			}
+			if got := run("big src"); got != "dir=src mb=10\n" {
+				t.Errorf("default not applied: %q", got)
+			}
+			if got := run("big src 50"); got != "dir=src mb=50\n" {
+				t.Errorf("explicit argument overridden: %q", got)
+			}
+			if got := run("ll -h"); got != "ls -h\n" {
+				t.Errorf("forwarding shortcut should pass -h through, got %q", got)
+			}
+		})
+	}
+}
+
+func TestFishPrelude(t *testing.T) {
+	sc := Shortcut{Command: "x", Description: "it's", Args: []Arg{{Name: "a"}, {Name: "b", Default: `c\d`}}}
+	got := strings.Join(sc.fishPrelude("n"), "\n")
+	for _, want := range []string{
+		`if contains -- "$argv[1]" -h --help`,
+		`printf '%s\n' 'Usage: n A [B=c\\d]' 'it\'s'`,
+		`test (count $argv) -eq 1; and set -a argv 'c\\d'`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("fish prelude missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	md := Markdown(map[string]Shortcut{
+		"gs":  {Command: "git status | head"},
+		"big": {Command: "x", Description: "Large files", Example: "big src", Args: []Arg{{Name: "dir"}}},
+	})
+	want := "| Usage | Description | Example |\n" +
+		"|-------|-------------|---------|\n" +
+		"| `big DIR` | Large files | `big src` |\n" +
+		"| `gs` | Runs `git status \\| head` |  |\n"
+	if md != want {
+		t.Errorf("Markdown =\n%s\nwant\n%s", md, want)
+	}
+}
@@ -34,46 +34,102 @@ func globalFilePath() (string, error) {
 	return filepath.Join(dir, "shortcuts.yml"), nil
 }
 
-// shortcutsFile is the YAML structure for ~/.dolly/shortcuts.yml
+// shortcutsFile is the YAML structure for ~/.dolly/shortcuts.yml and project
+// .dolly/shortcuts.yml files. Only the global file's groups and sources are
+// used.
 type shortcutsFile struct {
-	Shortcuts map[string]string `yaml:"shortcuts"`
+	Shortcuts map[string]Shortcut            `yaml:"shortcuts"`
+	Groups    map[string]map[string]Shortcut `yaml:"groups,omitempty"`
+	Sources   []string                       `yaml:"shortcut_sources,omitempty"`
 }
 
 // LoadGlobal reads ~/.dolly/shortcuts.yml and returns the user's global
-// shortcuts. Returns an empty map (not an error) if the file does not exist.
-func LoadGlobal() (map[string]string, error) {
-	path, err := globalFilePath()
+// shortcuts that are not in a group. Returns an empty map (not an error) if
+// the file does not exist.
+func LoadGlobal() (map[string]Shortcut, error) {
+	f, err := loadGlobalFile()
 	if err != nil {
-		return map[string]string{}, err
+		return map[string]Shortcut{}, err
+	}
+	if f.Shortcuts == nil {
+		return map[string]Shortcut{}, nil
 	}
+	return f.Shortcuts, nil
+}
 
-	data, err := os.ReadFile(path)
+// SaveGlobal writes the given shortcuts to ~/.dolly/shortcuts.yml atomically.
+// Only the shortcuts: key is replaced; groups and any other keys are kept.
+func SaveGlobal(shortcuts map[string]Shortcut) error {
+	path, doc, err := readGlobalDoc()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return map[string]string{}, nil
-		}
-		return map[string]string{}, fmt.Errorf("could not read %s: %w", path, err)
+		return err
 	}
 
-	var f shortcutsFile
-	if err := yaml.Unmarshal(data, &f); err != nil {
-		return map[string]string{}, fmt.Errorf("could not parse %s: %w", path, err)
+	var value yaml.Node
+	if err := value.Encode(shortcuts); err != nil {
+		return fmt.Errorf("could not marshal shortcuts: %w", err)
 	}
-	if f.Shortcuts == nil {
-		return map[string]string{}, nil
+	root := doc.Content[0]
+	if i := mappingIndex(root, "shortcuts"); i >= 0 {
+		root.Content[i+1] = &value
+	} else {
+		root.Content = append([]*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: "shortcuts"}, &value}, root.Content...)
 	}
-	return f.Shortcuts, nil
+	return writeGlobalDoc(path, doc)
 }
 
-// SaveGlobal writes the given shortcuts to ~/.dolly/shortcuts.yml atomically.
-func SaveGlobal(shortcuts map[string]string) error {
-	path, err := globalFilePath()
+// removeGrouped deletes name from the given group in ~/.dolly/shortcuts.yml,
+// leaving the rest of the file as it is.
+func removeGrouped(group, name string) error {
+	path, doc, err := readGlobalDoc()
 	if err != nil {
 		return err
 	}
+	root := doc.Content[0]
+	gi := mappingIndex(root, "groups")
+	if gi < 0 {
+		return fmt.Errorf("shortcut %q not found in group %q", name, group)
+	}
+	groups := root.Content[gi+1]
+	i := mappingIndex(groups, group)
+	if i < 0 {
+		return fmt.Errorf("shortcut %q not found in group %q", name, group)
+	}
+	members := groups.Content[i+1]
+	j := mappingIndex(members, name)
+	if j < 0 {
+		return fmt.Errorf("shortcut %q not found in group %q", name, group)
+	}
+	members.Content = append(members.Content[:j], members.Content[j+2:]...)
+	return writeGlobalDoc(path, doc)
+}
 
-	f := shortcutsFile{Shortcuts: shortcuts}
-	data, err := yaml.Marshal(&f)
+// readGlobalDoc parses ~/.dolly/shortcuts.yml as a YAML node tree so that
+// edits keep comments and unknown keys. A missing or non-mapping file yields
+// an empty mapping document.
+func readGlobalDoc() (string, *yaml.Node, error) {
+	path, err := globalFilePath()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var doc yaml.Node
+	if data, err := os.ReadFile(path); err == nil {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return "", nil, fmt.Errorf("could not parse %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	return path, &doc, nil
+}
+
+// writeGlobalDoc writes doc to path via a temp file and rename.
+func writeGlobalDoc(path string, doc *yaml.Node) error {
+	data, err := yaml.Marshal(doc)
 	if err != nil {
 		return fmt.Errorf("could not marshal shortcuts: %w", err)
 	}
@@ -89,18 +145,27 @@ func SaveGlobal(shortcuts map[string]string) error {
 	return nil
 }
 
-// Merge combines shortcut layers. Priority: session > global > defaults.
-// Any layer may be nil.
-func Merge(defaults, global, session map[string]string) map[string]string {
-	merged := make(map[string]string)
-	for k, v := range defaults {
-		merged[k] = v
+// mappingIndex returns the index of key in a mapping node's Content, or -1.
+func mappingIndex(m *yaml.Node, key string) int {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return -1
 	}
-	for k, v := range global {
-		merged[k] = v
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i
+		}
 	}
-	for k, v := range session {
-		merged[k] = v
+	return -1
+}
+
+// Merge combines shortcut layers. Priority: session > project > global >
+// defaults. Any layer may be nil.
+func Merge(defaults, global, project, session map[string]Shortcut) map[string]Shortcut {
+	merged := make(map[string]Shortcut)
+	for _, layer := range []map[string]Shortcut{defaults, global, project, session} {
+		for k, v := range layer {
+			merged[k] = v
+		}
 	}
 	return merged
 }
@@ -118,7 +183,9 @@ func ValidateName(name string) (warning string, err error) {
 	return "", nil
 }
 
-// AddGlobal adds or updates a shortcut in ~/.dolly/shortcuts.yml.
+// AddGlobal adds or updates a shortcut in ~/.dolly/shortcuts.yml. Updating
+// a shortcut replaces only its command; the description, example and args
+// are kept.
 func AddGlobal(name, command string) (warning string, err error) {
 	warn, err := ValidateName(name)
 	if err != nil {
@@ -129,30 +196,40 @@ func AddGlobal(name, command string) (warning string, err error) {
 	if err != nil {
 		return "", err
 	}
-	shortcuts[name] = command
+	sc := shortcuts[name]
+	sc.Command = command
+	shortcuts[name] = sc
 	if err := SaveGlobal(shortcuts); err != nil {
 		return "", err
 	}
 	return warn, nil
 }
 
-// RemoveGlobal removes a shortcut from ~/.dolly/shortcuts.yml.
-func RemoveGlobal(name string) error {
-	shortcuts, err := LoadGlobal()
+// RemoveGlobal removes a shortcut from ~/.dolly/shortcuts.yml. An ungrouped
+// shortcut is removed first; otherwise the user group defining name loses
+// it, and that group's name is returned.
+func RemoveGlobal(name string) (group string, err error) {
+	f, err := loadGlobalFile()
 	if err != nil {
-		return err
+		return "", err
 	}
-	if _, exists := shortcuts[name]; !exists {
-		return fmt.Errorf("shortcut %q not found in global shortcuts", name)
+	if _, exists := f.Shortcuts[name]; exists {
+		delete(f.Shortcuts, name)
+		return "", SaveGlobal(f.Shortcuts)
 	}
-	delete(shortcuts, name)
-	return SaveGlobal(shortcuts)
+	group = UserGroupOf(f.Groups, name)
+	if group == "" {
+		return "", fmt.Errorf("shortcut %q not found in global shortcuts", name)
+	}
+	return group, removeGrouped(group, name)
 }
 
 // WriteShellFile writes merged shortcuts as shell functions to a session-scoped
 // file under ~/.dolly/. Returns the file path. The file includes DOLLY_SESSION
-// and DOLLY_SHORTCUTS_FILE environment variables for introspection.
-func WriteShellFile(sessionName, terminal string, shortcuts map[string]string) (string, error) {
+// and DOLLY_SHORTCUTS_FILE environment variables for introspection, and a
+// prompt hook that re-sources it whenever it is rewritten. Every function
+// prints its usage for -h or --help.
+func WriteShellFile(sessionName, terminal string, shortcuts map[string]Shortcut) (string, error) {
 	if len(shortcuts) == 0 {
 		return "", nil
 	}
@@ -161,21 +238,26 @@ func WriteShellFile(sessionName, terminal string, shortcuts map[string]string) (
 	if err != nil {
 		return "", err
 	}
+	return writeShellFileIn(dir, sessionName, terminal, shortcuts)
+}
 
-	isFish := strings.ToLower(terminal) == "fish"
-	ext := ".sh"
-	if isFish {
-		ext = ".fish"
-	}
-	path := filepath.Join(dir, fmt.Sprintf(".shortcuts_%s%s", sessionName, ext))
+// writeShellFileIn is WriteShellFile with the target directory given, so
+// `dolly shortcuts check` can render into a scratch directory.
+func writeShellFileIn(dir, sessionName, terminal string, shortcuts map[string]Shortcut) (string, error) {
+	kind := shellKind(terminal)
+	path := filepath.Join(dir, fmt.Sprintf(".shortcuts_%s%s", sessionName, fileExt(kind)))
 
 	var b strings.Builder
 
 	// Environment variables for introspection
-	if isFish {
+	switch kind {
+	case ShellFish:
 		fmt.Fprintf(&b, "set -gx DOLLY_SESSION %q\n", sessionName)
 		fmt.Fprintf(&b, "set -gx DOLLY_SHORTCUTS_FILE %q\n", path)
-	} else {
+	case ShellNu:
+		fmt.Fprintf(&b, "$env.DOLLY_SESSION = %q\n", sessionName)
+		fmt.Fprintf(&b, "$env.DOLLY_SHORTCUTS_FILE = %q\n", path)
+	default:
 		fmt.Fprintf(&b, "export DOLLY_SESSION=%q\n", sessionName)
 		fmt.Fprintf(&b, "export DOLLY_SHORTCUTS_FILE=%q\n", path)
 	}
@@ -189,27 +271,97 @@ func WriteShellFile(sessionName, terminal string, shortcuts map[string]string) (
 	sort.Strings(names)
 
 	for _, name := range names {
-		cmd := shortcuts[name]
-		if isFish {
-			fmt.Fprintf(&b, "function %s\n    %s\nend\n\n", name, cmd)
-		} else {
-			fmt.Fprintf(&b, "function %s() {\n    %s\n}\n\n", name, cmd)
+		sc := shortcuts[name]
+		cmd := CommandFor(kind, name, sc.Command)
+		switch kind {
+		case ShellFish:
+			body := strings.ReplaceAll(strings.Join(append(sc.fishPrelude(name), cmd), "\n"), "\n", "\n    ")
+			fmt.Fprintf(&b, "function %s\n    %s\nend\n\n", name, body)
+		case ShellNu:
+			b.WriteString(nuFunction(name, sc.Description, strings.Join(append(sc.shPrelude(name, "exit"), cmd), "\n")))
+		case ShellSh:
+			// `function` is a bash/zsh keyword; dash only accepts name()
+			body := strings.Join(append(sc.shPrelude(name, "return"), cmd), "\n    ")
+			fmt.Fprintf(&b, "%s() {\n    %s\n}\n\n", name, body)
+		default:
+			body := strings.Join(append(sc.shPrelude(name, "return"), cmd), "\n    ")
+			fmt.Fprintf(&b, "function %s() {\n    %s\n}\n\n", name, body)
 		}
 	}
 
-	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+	// nu defines commands at parse time, so a prompt hook cannot redefine them
+	content := b.String()
+	if kind != ShellNu {
+		gen := contentHash([]byte(content))[:16]
+		writeReloadHook(&b, kind == ShellFish, names, gen)
+		content = generationPrefix + gen + "\n" + b.String()
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
 		return "", fmt.Errorf("could not write shortcuts file: %w", err)
 	}
 	return path, nil
 }
 
+// generationPrefix starts the first line of a shortcuts file with a reload
+// hook. The rest of the line is a hash of the shortcuts, so the hook sees
+// every rewrite that changes them, even several within the same second.
+const generationPrefix = "# dolly shortcuts generation "
+
+// writeReloadHook appends a prompt hook that re-sources the shortcuts file
+// when the generation on its first line no longer matches the one this copy
+// was written with, so `dolly shortcuts add` and `sync` reach running panes
+// at their next prompt. Functions from the previous version are removed
+// first so deleted shortcuts disappear too. The .sh file is shared by bash
+// and zsh, so the hook picks PROMPT_COMMAND or precmd at runtime.
+func writeReloadHook(b *strings.Builder, isFish bool, names []string, gen string) {
+	b.WriteString("# Re-source this file at the next prompt whenever dolly rewrites it\n")
+	if isFish {
+		fmt.Fprintf(b, "set -g DOLLY_SHORTCUTS_NAMES %s\n", strings.Join(names, " "))
+		fmt.Fprintf(b, "set -g DOLLY_SHORTCUTS_GEN %s\n", gen)
+		b.WriteString("function _dolly_shortcuts_reload --on-event fish_prompt\n")
+		b.WriteString("    test -r $DOLLY_SHORTCUTS_FILE; or return\n")
+		b.WriteString("    read -l line < $DOLLY_SHORTCUTS_FILE\n")
+		b.WriteString("    set -l gen (string split ' ' -- $line)[-1]\n")
+		b.WriteString("    if test -n \"$gen\"; and test \"$gen\" != \"$DOLLY_SHORTCUTS_GEN\"\n")
+		b.WriteString("        for name in $DOLLY_SHORTCUTS_NAMES\n")
+		b.WriteString("            functions -e $name\n")
+		b.WriteString("        end\n")
+		b.WriteString("        source $DOLLY_SHORTCUTS_FILE\n")
+		b.WriteString("    end\n")
+		b.WriteString("end\n")
+		return
+	}
+
+	fmt.Fprintf(b, "DOLLY_SHORTCUTS_NAMES=%q\n", strings.Join(names, " "))
+	fmt.Fprintf(b, "DOLLY_SHORTCUTS_GEN=%s\n", gen)
+	b.WriteString("_dolly_shortcuts_reload() {\n")
+	b.WriteString("    local line=\n")
+	b.WriteString("    { IFS= read -r line < \"$DOLLY_SHORTCUTS_FILE\"; } 2>/dev/null\n")
+	b.WriteString("    line=${line##* }\n")
+	b.WriteString("    if [ -n \"$line\" ] && [ \"$line\" != \"$DOLLY_SHORTCUTS_GEN\" ]; then\n")
+	b.WriteString("        eval \"unset -f $DOLLY_SHORTCUTS_NAMES\"\n")
+	b.WriteString("        . \"$DOLLY_SHORTCUTS_FILE\"\n")
+	b.WriteString("    fi\n")
+	b.WriteString("}\n")
+	b.WriteString("if [ -n \"$ZSH_VERSION\" ]; then\n")
+	b.WriteString("    autoload -Uz add-zsh-hook\n")
+	b.WriteString("    add-zsh-hook precmd _dolly_shortcuts_reload\n")
+	b.WriteString("elif [ -n \"$BASH_VERSION\" ]; then\n")
+	b.WriteString("    case \";$PROMPT_COMMAND;\" in\n")
+	b.WriteString("        *\";_dolly_shortcuts_reload;\"*) ;;\n")
+	b.WriteString("        *) PROMPT_COMMAND=\"_dolly_shortcuts_reload${PROMPT_COMMAND:+;$PROMPT_COMMAND}\" ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("fi\n")
+}
+
 // CleanupShellFile removes the session-scoped shortcuts file(s) for a session.
 func CleanupShellFile(sessionName string) {
 	dir, err := dollyDir()
 	if err != nil {
 		return
 	}
-	for _, ext := range []string{".sh", ".fish"} {
+	for _, ext := range []string{".sh", ".fish", ".nu"} {
 		path := filepath.Join(dir, fmt.Sprintf(".shortcuts_%s%s", sessionName, ext))
 		os.Remove(path)
 	}
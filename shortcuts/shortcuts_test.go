@@ -2,52 +2,57 @@ package shortcuts
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 )
 
 func TestMerge(t *testing.T) {
-	defaults := map[string]string{"gs": "git status", "gl": "git log"}
-	global := map[string]string{"gs": "git status -sb", "deploy": "./deploy.sh"}
-	session := map[string]string{"gs": "git status -v", "test": "go test ./..."}
+	defaults := map[string]Shortcut{"gs": {Command: "git status"}, "gl": {Command: "git log"}}
+	global := map[string]Shortcut{"gs": {Command: "git status -sb"}, "deploy": {Command: "./deploy.sh"}}
+	project := map[string]Shortcut{"gs": {Command: "git status -s"}, "deploy": {Command: "make deploy"}, "migrate": {Command: "make migrate"}}
+	session := map[string]Shortcut{"gs": {Command: "git status -v"}, "test": {Command: "go test ./..."}}
 
-	merged := Merge(defaults, global, session)
+	merged := Merge(defaults, global, project, session)
 
-	// session overrides global overrides defaults
-	if merged["gs"] != "git status -v" {
-		t.Errorf("expected session override for gs, got %q", merged["gs"])
+	// session overrides project overrides global overrides defaults
+	if merged["gs"].Command != "git status -v" {
+		t.Errorf("expected session override for gs, got %q", merged["gs"].Command)
 	}
-	if merged["gl"] != "git log" {
-		t.Errorf("expected default for gl, got %q", merged["gl"])
+	if merged["deploy"].Command != "make deploy" {
+		t.Errorf("expected project override for deploy, got %q", merged["deploy"].Command)
 	}
-	if merged["deploy"] != "./deploy.sh" {
-		t.Errorf("expected global for deploy, got %q", merged["deploy"])
+	if merged["migrate"].Command != "make migrate" {
+		t.Errorf("expected project shortcut migrate, got %q", merged["migrate"].Command)
 	}
-	if merged["test"] != "go test ./..." {
-		t.Errorf("expected session for test, got %q", merged["test"])
+	if merged["gl"].Command != "git log" {
+		t.Errorf("expected default for gl, got %q", merged["gl"].Command)
+	}
+	if merged["test"].Command != "go test ./..." {
+		t.Errorf("expected session for test, got %q", merged["test"].Command)
 	}
 }
 
 func TestMergeNilDefaults(t *testing.T) {
-	global := map[string]string{"deploy": "./deploy.sh"}
-	session := map[string]string{"test": "go test ./..."}
+	global := map[string]Shortcut{"deploy": {Command: "./deploy.sh"}}
+	session := map[string]Shortcut{"test": {Command: "go test ./..."}}
 
-	merged := Merge(nil, global, session)
+	merged := Merge(nil, global, nil, session)
 
 	if _, ok := merged["gs"]; ok {
 		t.Error("expected no defaults when defaults is nil")
 	}
-	if merged["deploy"] != "./deploy.sh" {
-		t.Errorf("expected global shortcut, got %q", merged["deploy"])
+	if merged["deploy"].Command != "./deploy.sh" {
+		t.Errorf("expected global shortcut, got %q", merged["deploy"].Command)
 	}
-	if merged["test"] != "go test ./..." {
-		t.Errorf("expected session shortcut, got %q", merged["test"])
+	if merged["test"].Command != "go test ./..." {
+		t.Errorf("expected session shortcut, got %q", merged["test"].Command)
 	}
 }
 
 func TestMergeAllNil(t *testing.T) {
-	merged := Merge(nil, nil, nil)
+	merged := Merge(nil, nil, nil, nil)
 	if len(merged) != 0 {
 		t.Errorf("expected empty map, got %d entries", len(merged))
 	}
@@ -120,12 +125,25 @@ func TestAddAndRemoveGlobal(t *testing.T) {
 	if err != nil {
 		t.Fatalf("LoadGlobal after add: %v", err)
 	}
-	if sc["mygrep"] != `grep -rn "$1" .` {
-		t.Errorf("expected mygrep shortcut, got %q", sc["mygrep"])
+	if sc["mygrep"].Command != `grep -rn "$1" .` {
+		t.Errorf("expected mygrep shortcut, got %q", sc["mygrep"].Command)
+	}
+
+	// Updating replaces the command and keeps the documentation
+	sc["mygrep"] = Shortcut{Command: sc["mygrep"].Command, Description: "Search here", Args: []Arg{{Name: "pattern"}}}
+	if err := SaveGlobal(sc); err != nil {
+		t.Fatalf("SaveGlobal: %v", err)
+	}
+	if _, err := AddGlobal("mygrep", `grep -rni "$1" .`); err != nil {
+		t.Fatalf("AddGlobal update: %v", err)
+	}
+	sc, _ = LoadGlobal()
+	if got := sc["mygrep"]; got.Command != `grep -rni "$1" .` || got.Description != "Search here" || len(got.Args) != 1 {
+		t.Errorf("update lost metadata or command: %+v", got)
 	}
 
 	// Remove
-	if err := RemoveGlobal("mygrep"); err != nil {
+	if _, err := RemoveGlobal("mygrep"); err != nil {
 		t.Fatalf("RemoveGlobal: %v", err)
 	}
 
@@ -142,7 +160,7 @@ func TestRemoveGlobalNotFound(t *testing.T) {
 	tmp := t.TempDir()
 	t.Setenv("HOME", tmp)
 
-	err := RemoveGlobal("nonexistent")
+	_, err := RemoveGlobal("nonexistent")
 	if err == nil {
 		t.Error("expected error removing nonexistent shortcut")
 	}
@@ -152,9 +170,9 @@ func TestWriteShellFileBashZsh(t *testing.T) {
 	tmp := t.TempDir()
 	t.Setenv("HOME", tmp)
 
-	sc := map[string]string{
-		"gs": "git status",
-		"ff": `find . -type f -name "$1"`,
+	sc := map[string]Shortcut{
+		"gs": {Command: "git status"},
+		"ff": {Command: `find . -type f -name "$1"`},
 	}
 
 	path, err := WriteShellFile("test-session", "zsh", sc)
@@ -194,8 +212,8 @@ func TestWriteShellFileFish(t *testing.T) {
 	tmp := t.TempDir()
 	t.Setenv("HOME", tmp)
 
-	sc := map[string]string{
-		"gs": "git status",
+	sc := map[string]Shortcut{
+		"gs": {Command: "git status"},
 	}
 
 	path, err := WriteShellFile("fish-session", "fish", sc)
@@ -232,7 +250,7 @@ func TestWriteShellFileFish(t *testing.T) {
 }
 
 func TestWriteShellFileEmpty(t *testing.T) {
-	path, err := WriteShellFile("empty", "bash", map[string]string{})
+	path, err := WriteShellFile("empty", "bash", map[string]Shortcut{})
 	if err != nil {
 		t.Fatalf("WriteShellFile with empty map: %v", err)
 	}
@@ -241,11 +259,91 @@ func TestWriteShellFileEmpty(t *testing.T) {
 	}
 }
 
+func TestWriteShellFileReloadHook(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	sc := map[string]Shortcut{"gs": {Command: "git status"}, "ff": {Command: "find ."}}
+
+	path, err := WriteShellFile("hooked", "bash", sc)
+	if err != nil {
+		t.Fatalf("WriteShellFile: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	content := string(data)
+	for _, want := range []string{
+		`DOLLY_SHORTCUTS_NAMES="ff gs"`,
+		"DOLLY_SHORTCUTS_GEN=",
+		"add-zsh-hook precmd _dolly_shortcuts_reload",
+		`PROMPT_COMMAND="_dolly_shortcuts_reload`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("sh hook missing %q", want)
+		}
+	}
+
+	path, err = WriteShellFile("hooked", "fish", sc)
+	if err != nil {
+		t.Fatalf("WriteShellFile: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	content = string(data)
+	for _, want := range []string{
+		"set -g DOLLY_SHORTCUTS_NAMES ff gs",
+		"set -g DOLLY_SHORTCUTS_GEN ",
+		"function _dolly_shortcuts_reload --on-event fish_prompt",
+		"functions -e $name",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("fish hook missing %q", want)
+		}
+	}
+}
+
+// TestReloadHookBash sources a shortcuts file in bash, rewrites it the way
+// `dolly shortcuts add` does, and runs the prompt hook: the new shortcut must
+// appear and the removed one must be gone.
+func TestReloadHookBash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	path, err := WriteShellFile("reload", "bash", map[string]Shortcut{"old_sc": {Command: "echo old"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(path)
+	if _, err := WriteShellFile("reload", "bash", map[string]Shortcut{"new_sc": {Command: "echo new"}}); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.ReadFile(path)
+	next := path + ".next"
+	os.WriteFile(next, after, 0644)
+	os.WriteFile(path, before, 0644)
+	// Both versions are written within the same second, so only the
+	// generation line tells them apart
+
+	script := `. "$1"
+case "$PROMPT_COMMAND" in *_dolly_shortcuts_reload*) ;; *) echo "hook not installed"; exit 1 ;; esac
+cat "$2" > "$1"
+_dolly_shortcuts_reload
+new_sc
+type old_sc >/dev/null 2>&1 && echo "old_sc still defined"
+exit 0`
+	out, err := exec.Command(bash, "-c", script, "bash", path, next).CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash: %v\n%s", err, out)
+	}
+	if got := strings.TrimSpace(string(out)); got != "new" {
+		t.Errorf("after reload got %q, want %q", got, "new")
+	}
+}
+
 func TestCleanupShellFile(t *testing.T) {
 	tmp := t.TempDir()
 	t.Setenv("HOME", tmp)
 
-	sc := map[string]string{"gs": "git status"}
+	sc := map[string]Shortcut{"gs": {Command: "git status"}}
 	path, err := WriteShellFile("cleanup-test", "bash", sc)
 	if err != nil {
 		t.Fatalf("WriteShellFile: %v", err)
@@ -0,0 +1,116 @@
+package shortcuts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrUntrusted is returned by LoadProject for a project file the user has
+// not trusted, or that changed since it was trusted.
+var ErrUntrusted = errors.New("not trusted")
+
+// trustStore is ~/.dolly/trusted-shortcuts.json. It maps each trusted
+// project file to the SHA-256 of the content the user reviewed, so a pull
+// that changes the file needs a new `dolly shortcuts trust`.
+type trustStore struct {
+	Files map[string]string `json:"files"`
+}
+
+func trustFilePath() (string, error) {
+	dir, err := dollyDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "trusted-shortcuts.json"), nil
+}
+
+func loadTrust() (*trustStore, error) {
+	store := &trustStore{Files: map[string]string{}}
+	path, err := trustFilePath()
+	if err != nil {
+		return store, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return store, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return store, fmt.Errorf("could not parse %s: %w", path, err)
+	}
+	if store.Files == nil {
+		store.Files = map[string]string{}
+	}
+	return store, nil
+}
+
+func saveTrust(store *trustStore) error {
+	path, err := trustFilePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("could not write %s: %w", path, err)
+	}
+	return nil
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// isTrusted reports whether path was trusted with exactly this content.
+func isTrusted(path string, data []byte) bool {
+	store, err := loadTrust()
+	return err == nil && store.Files[path] == contentHash(data)
+}
+
+// Trust records the project file found from dir as reviewed, after checking
+// that LoadProject would accept it. Returns the file and its shortcuts.
+func Trust(dir string) (string, map[string]Shortcut, error) {
+	path := FindProjectFile(dir)
+	if path == "" {
+		return "", nil, fmt.Errorf("no %s at or above %s", projectFileName, dir)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return path, nil, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	sc, err := parseProject(path, data)
+	if err != nil {
+		return path, nil, err
+	}
+	store, err := loadTrust()
+	if err != nil {
+		return path, nil, err
+	}
+	store.Files[path] = contentHash(data)
+	return path, sc, saveTrust(store)
+}
+
+// Untrust forgets the project file found from dir. Returns the file, or ""
+// when it was not trusted.
+func Untrust(dir string) (string, error) {
+	path := FindProjectFile(dir)
+	store, err := loadTrust()
+	if err != nil || path == "" {
+		return "", err
+	}
+	if _, ok := store.Files[path]; !ok {
+		return "", nil
+	}
+	delete(store.Files, path)
+	return path, saveTrust(store)
+}
@@ -0,0 +1,75 @@
+package throwaway
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"tmux-manager/internal/dolly"
+	"tmux-manager/registry"
+)
+
+// ParseLimit parses a -ttl or -idle value: a positive Go duration (90m, 4h,
+// 1h30m), or whole days or weeks (2d, 1w). An empty string means no limit.
+func ParseLimit(s string) (time.Duration, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	d, err := dolly.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d == 0 {
+		return 0, fmt.Errorf("invalid duration %q: must be greater than zero", s)
+	}
+	return d, nil
+}
+
+// formatLimit renders a limit for the registry, dropping zero minute and
+// second parts so 4h is stored as "4h" rather than "4h0m0s".
+func formatLimit(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// Expired reports whether a live throwaway session should be killed: it was
+// created longer than its TTL ago, or tmux has seen no activity in it for
+// longer than its idle limit. lastActivity is tmux's #{session_activity};
+// when zero the entry's LastActive is used. The reason is suitable for
+// printing and the history log.
+func Expired(e registry.Entry, lastActivity, now time.Time) (bool, string) {
+	if ttl, err := ParseLimit(e.TTL); err == nil && ttl > 0 {
+		if age := now.Sub(e.CreatedAt); age >= ttl {
+			return true, fmt.Sprintf("ttl %s exceeded (age %s)", e.TTL, formatAge(age))
+		}
+	}
+	if idle, err := ParseLimit(e.Idle); err == nil && idle > 0 {
+		if lastActivity.IsZero() {
+			lastActivity = e.LastActive
+		}
+		if quiet := now.Sub(lastActivity); quiet >= idle {
+			return true, fmt.Sprintf("idle for %s (limit %s)", formatAge(quiet), e.Idle)
+		}
+	}
+	return false, ""
+}
+
+// formatAge renders a duration rounded to minutes for messages.
+func formatAge(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	return formatLimit(d.Round(time.Minute))
+}
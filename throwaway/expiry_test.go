@@ -0,0 +1,89 @@
+package throwaway
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tmux-manager/registry"
+)
+
+func TestParseLimit(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":      0,
+		"90m":   90 * time.Minute,
+		"4h":    4 * time.Hour,
+		"1h30m": 90 * time.Minute,
+		"2d":    48 * time.Hour,
+		"1w":    7 * 24 * time.Hour,
+	}
+	for in, want := range cases {
+		got, err := ParseLimit(in)
+		if err != nil || got != want {
+			t.Errorf("ParseLimit(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"soon", "0h", "-1h", "0d", "1.5d"} {
+		if _, err := ParseLimit(bad); err == nil {
+			t.Errorf("ParseLimit(%q) should fail", bad)
+		}
+	}
+}
+
+func TestFormatLimit(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                               "",
+		4 * time.Hour:                   "4h",
+		90 * time.Minute:                "1h30m",
+		45 * time.Minute:                "45m",
+		48 * time.Hour:                  "2d",
+		26 * time.Hour:                  "26h",
+		30*time.Minute + 15*time.Second: "30m15s",
+	} {
+		if got := formatLimit(d); got != want {
+			t.Errorf("formatLimit(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+// ─── Expired ─────────────────────────────────────────────────────────────────
+
+func TestExpired(t *testing.T) {
+	now := time.Date(2024, 5, 6, 18, 0, 0, 0, time.UTC)
+	entry := func(createdAgo, lastActiveAgo time.Duration, ttl, idle string) registry.Entry {
+		return registry.Entry{
+			Name:       "tw",
+			Type:       registry.TypeThrowaway,
+			CreatedAt:  now.Add(-createdAgo),
+			LastActive: now.Add(-lastActiveAgo),
+			TTL:        ttl,
+			Idle:       idle,
+		}
+	}
+
+	cases := []struct {
+		name       string
+		e          registry.Entry
+		activity   time.Time
+		want       bool
+		reasonPart string
+	}{
+		{"no limits never expire", entry(30*24*time.Hour, 30*24*time.Hour, "", ""), time.Time{}, false, ""},
+		{"within ttl", entry(3*time.Hour, 0, "4h", ""), time.Time{}, false, ""},
+		{"past ttl", entry(5*time.Hour, 0, "4h", ""), now, true, "ttl 4h"},
+		{"busy session within idle", entry(10*time.Hour, 0, "", "2h"), now.Add(-time.Hour), false, ""},
+		{"idle by tmux activity", entry(10*time.Hour, 0, "", "2h"), now.Add(-3 * time.Hour), true, "idle for 3h"},
+		{"idle falls back to LastActive", entry(10*time.Hour, 5*time.Hour, "", "2h"), time.Time{}, true, "limit 2h"},
+		{"day ttl", entry(49*time.Hour, 0, "2d", ""), now, true, "ttl 2d"},
+	}
+	for _, c := range cases {
+		got, reason := Expired(c.e, c.activity, now)
+		if got != c.want {
+			t.Errorf("%s: Expired = %v, want %v (reason %q)", c.name, got, c.want, reason)
+			continue
+		}
+		if !strings.Contains(reason, c.reasonPart) {
+			t.Errorf("%s: reason %q does not mention %q", c.name, reason, c.reasonPart)
+		}
+	}
+}
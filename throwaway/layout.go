@@ -0,0 +1,82 @@
+package throwaway
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"tmux-manager/config"
+)
+
+// layouts maps the -layout names to tmux select-layout presets.
+var layouts = map[string]string{
+	"grid":      "tiled",
+	"tiled":     "tiled",
+	"main-left": "main-vertical",
+	"stacked":   "even-vertical",
+}
+
+// LayoutNames returns the accepted -layout values, sorted.
+func LayoutNames() []string {
+	names := make([]string, 0, len(layouts))
+	for n := range layouts {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyLayout sets every window of a throwaway config to the named layout.
+// An empty name keeps the default side-by-side splits. Stacked layouts split
+// horizontally so tall narrow panes are never created on the way.
+func ApplyLayout(cfg *config.TmuxConfig, name string) error {
+	if name == "" {
+		return nil
+	}
+	tmuxLayout, ok := layouts[name]
+	if !ok {
+		return fmt.Errorf("invalid layout %q (use %s)", name, strings.Join(LayoutNames(), ", "))
+	}
+	for wi := range cfg.Windows {
+		cfg.Windows[wi].Layout = tmuxLayout
+		if name != "stacked" {
+			continue
+		}
+		for pi := range cfg.Windows[wi].Panes {
+			if pi > 0 {
+				cfg.Windows[wi].Panes[pi].Split = "horizontal"
+			}
+		}
+	}
+	return nil
+}
+
+// ParseCommandList splits a -cmd value on commas. Unlike
+// config.ParseCommands, empty entries are kept so "htop,,git status" leaves
+// the second pane as a plain shell.
+func ParseCommandList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// ApplyCommands assigns commands to panes in order: every pane of the first
+// window, then the next window. Options.Validate rejects more commands than
+// panes, so callers validate first.
+func ApplyCommands(cfg *config.TmuxConfig, commands []string) {
+	i := 0
+	for wi := range cfg.Windows {
+		for pi := range cfg.Windows[wi].Panes {
+			if i == len(commands) {
+				return
+			}
+			cfg.Windows[wi].Panes[pi].Command = commands[i]
+			i++
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package throwaway
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ─── Layouts ─────────────────────────────────────────────────────────────────
+
+func TestApplyLayout(t *testing.T) {
+	cases := map[string]string{
+		"grid":      "tiled",
+		"tiled":     "tiled",
+		"main-left": "main-vertical",
+		"stacked":   "even-vertical",
+	}
+	for name, want := range cases {
+		cfg, _ := BuildThrowawayConfig("tw", "/tmp", 2, 3)
+		if err := ApplyLayout(cfg, name); err != nil {
+			t.Fatalf("ApplyLayout(%q): %v", name, err)
+		}
+		for _, w := range cfg.Windows {
+			if w.Layout != want {
+				t.Errorf("%s: window %s layout = %q, want %q", name, w.Name, w.Layout, want)
+			}
+		}
+	}
+}
+
+func TestApplyLayout_StackedSplitsHorizontally(t *testing.T) {
+	cfg, _ := BuildThrowawayConfig("tw", "/tmp", 1, 3)
+	ApplyLayout(cfg, "stacked")
+	panes := cfg.Windows[0].Panes
+	if panes[0].Split != "none" || panes[1].Split != "horizontal" || panes[2].Split != "horizontal" {
+		t.Fatalf("unexpected splits: %q %q %q", panes[0].Split, panes[1].Split, panes[2].Split)
+	}
+}
+
+func TestApplyLayout_DefaultAndInvalid(t *testing.T) {
+	cfg, _ := BuildThrowawayConfig("tw", "/tmp", 1, 2)
+	if err := ApplyLayout(cfg, ""); err != nil || cfg.Windows[0].Layout != "" {
+		t.Fatalf("empty layout should keep defaults, got %q, %v", cfg.Windows[0].Layout, err)
+	}
+	if err := ApplyLayout(cfg, "diagonal"); err == nil {
+		t.Fatal("expected error for unknown layout")
+	}
+}
+
+// ─── Commands ────────────────────────────────────────────────────────────────
+
+func TestParseCommandList_KeepsEmptyEntries(t *testing.T) {
+	got := ParseCommandList("htop,, git status ")
+	want := []string{"htop", "", "git status"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseCommandList = %q, want %q", got, want)
+	}
+	if ParseCommandList("  ") != nil {
+		t.Fatal("blank -cmd should yield no commands")
+	}
+}
+
+func TestApplyCommands_FillsPanesInOrder(t *testing.T) {
+	cfg, _ := BuildThrowawayConfig("tw", "/tmp", 2, 2)
+	ApplyCommands(cfg, []string{"htop", "", "git status"})
+	got := []string{
+		cfg.Windows[0].Panes[0].Command, cfg.Windows[0].Panes[1].Command,
+		cfg.Windows[1].Panes[0].Command, cfg.Windows[1].Panes[1].Command,
+	}
+	want := []string{"htop", "", "git status", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("pane commands = %q, want %q", got, want)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	ok := Options{Windows: 1, PanesPerWindow: 2, Layout: "grid", Commands: []string{"a", ""}}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	bad := []Options{
+		{Name: "bad name", Windows: 1, PanesPerWindow: 1},
+		{Windows: 0, PanesPerWindow: 1},
+		{Windows: 1, PanesPerWindow: 1, Layout: "diagonal"},
+		{Windows: 1, PanesPerWindow: 1, Commands: []string{"a", "b"}},
+		{Windows: 1, PanesPerWindow: 1, TTL: -1},
+	}
+	for i, o := range bad {
+		if err := o.Validate(); err == nil {
+			t.Errorf("case %d: expected validation error for %+v", i, o)
+		}
+	}
+}
+
+// ─── Presets ─────────────────────────────────────────────────────────────────
+
+func writePreset(t *testing.T, home, name, content string) {
+	t.Helper()
+	dir := filepath.Join(home, ".dolly", "presets")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPreset(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writePreset(t, home, "debug", `
+windows: 1
+panes: 3
+layout: main-left
+commands: ["htop", "", "tail -f /var/log/syslog"]
+ttl: 4h
+`)
+
+	p, err := LoadPreset("debug")
+	if err != nil {
+		t.Fatalf("LoadPreset: %v", err)
+	}
+	if p.Windows != 1 || p.Panes != 3 || p.Layout != "main-left" || p.TTL != "4h" {
+		t.Errorf("unexpected preset: %+v", p)
+	}
+	if len(p.Commands) != 3 || p.Commands[1] != "" {
+		t.Errorf("commands = %q", p.Commands)
+	}
+
+	names, _ := ListPresets()
+	if !reflect.DeepEqual(names, []string{"debug"}) {
+		t.Errorf("ListPresets = %v", names)
+	}
+}
+
+func TestLoadPreset_Errors(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writePreset(t, home, "debug", "layout: main-left\n")
+	writePreset(t, home, "broken", "layout: diagonal\n")
+
+	_, err := LoadPreset("missing")
+	if err == nil || !strings.Contains(err.Error(), "available: broken, debug") {
+		t.Errorf("missing preset should list available ones, got %v", err)
+	}
+	if _, err := LoadPreset("broken"); err == nil {
+		t.Error("expected error for unknown layout in preset")
+	}
+	if _, err := LoadPreset("../etc"); err == nil {
+		t.Error("expected error for invalid preset name")
+	}
+}
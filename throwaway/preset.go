@@ -0,0 +1,92 @@
+package throwaway
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"tmux-manager/internal/dolly"
+)
+
+// Preset is a named set of throwaway defaults stored as
+// ~/.dolly/presets/NAME.yml. Zero fields leave the built-in default alone;
+// flags given on the command line override the preset.
+type Preset struct {
+	Windows  int      `yaml:"windows,omitempty"`
+	Panes    int      `yaml:"panes,omitempty"`
+	Layout   string   `yaml:"layout,omitempty"`
+	Commands []string `yaml:"commands,omitempty"` // one per pane, "" for a plain shell
+	Dir      string   `yaml:"dir,omitempty"`
+	TTL      string   `yaml:"ttl,omitempty"`
+	Idle     string   `yaml:"idle,omitempty"`
+}
+
+// presetDir returns ~/.dolly/presets without creating it.
+func presetDir() (string, error) {
+	dir, err := dolly.DataDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine dolly data directory: %w", err)
+	}
+	return filepath.Join(dir, "presets"), nil
+}
+
+// ListPresets returns the names of all saved presets, sorted.
+func ListPresets() ([]string, error) {
+	dir, err := presetDir()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*.yml"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(m), ".yml"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// LoadPreset reads ~/.dolly/presets/NAME.yml. The layout and limits are
+// validated here so a broken preset is reported before anything is created.
+func LoadPreset(name string) (*Preset, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid preset name %q: use only letters, digits, underscores, and hyphens", name)
+	}
+	dir, err := presetDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, name+".yml")
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		available, _ := ListPresets()
+		if len(available) == 0 {
+			return nil, fmt.Errorf("preset %q not found: create %s", name, path)
+		}
+		return nil, fmt.Errorf("preset %q not found (available: %s)", name, strings.Join(available, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read preset %q: %w", name, err)
+	}
+
+	var p Preset
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid preset %s: %w", path, err)
+	}
+	if _, ok := layouts[p.Layout]; p.Layout != "" && !ok {
+		return nil, fmt.Errorf("invalid preset %s: unknown layout %q (use %s)", path, p.Layout, strings.Join(LayoutNames(), ", "))
+	}
+	for _, limit := range []string{p.TTL, p.Idle} {
+		if _, err := ParseLimit(limit); err != nil {
+			return nil, fmt.Errorf("invalid preset %s: %w", path, err)
+		}
+	}
+	return &p, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 	"time"
 
 	"tmux-manager/config"
@@ -67,13 +68,43 @@ func BuildThrowawayConfig(name, workingDir string, numWindows, panesPerWindow in
 	}, nil
 }
 
+// Validate reports option errors that are the user's to fix: a bad name,
+// layout or limit, or more commands than panes.
+func (o Options) Validate() error {
+	if o.Name != "" && !validName.MatchString(o.Name) {
+		return fmt.Errorf("invalid session name %q: use only letters, digits, underscores, and hyphens", o.Name)
+	}
+	if o.Windows < 1 {
+		return fmt.Errorf("windows must be >= 1, got %d", o.Windows)
+	}
+	if o.PanesPerWindow < 1 {
+		return fmt.Errorf("panes must be >= 1, got %d", o.PanesPerWindow)
+	}
+	if _, ok := layouts[o.Layout]; o.Layout != "" && !ok {
+		return fmt.Errorf("invalid layout %q (use %s)", o.Layout, strings.Join(LayoutNames(), ", "))
+	}
+	if total := o.Windows * o.PanesPerWindow; len(o.Commands) > total {
+		return fmt.Errorf("%d commands given but the session only has %d panes; raise -windows or -panes", len(o.Commands), total)
+	}
+	if o.TTL < 0 || o.Idle < 0 {
+		return fmt.Errorf("ttl and idle limits must not be negative")
+	}
+	return nil
+}
+
 // Create creates a throwaway tmux session and registers it in the dolly
-// registry. Returns the resolved session name (auto-generated if empty).
-func Create(name, workingDir string, numWindows, panesPerWindow int) (string, error) {
+// registry together with its TTL and idle limits. Returns the resolved
+// session name (auto-generated if empty).
+func Create(opts Options) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	name, workingDir := opts.Name, opts.WorkingDir
+	if name == "" && opts.Worktree != "" {
+		name = WorktreeSessionName(opts.Worktree)
+	}
 	if name == "" {
 		name = GenerateName()
-	} else if !validName.MatchString(name) {
-		return "", fmt.Errorf("invalid session name %q: use only letters, digits, underscores, and hyphens", name)
 	}
 
 	if workingDir == "" {
@@ -84,24 +115,65 @@ func Create(name, workingDir string, numWindows, panesPerWindow int) (string, er
 		}
 	}
 
-	cfg, err := BuildThrowawayConfig(name, workingDir, numWindows, panesPerWindow)
+	cfg, err := BuildThrowawayConfig(name, workingDir, opts.Windows, opts.PanesPerWindow)
 	if err != nil {
 		return "", err
 	}
+	if err := ApplyLayout(cfg, opts.Layout); err != nil {
+		return "", err
+	}
+	ApplyCommands(cfg, opts.Commands)
+
+	// The worktree is created last so a bad option never leaves one behind
+	var repo, worktree string
+	var newBranch bool
+	if opts.Worktree != "" {
+		if tmux.IsSessionAlive(name) {
+			return "", fmt.Errorf("session %q already exists", name)
+		}
+		dir := opts.WorktreeDir
+		if dir == "" {
+			dir = DefaultWorktreeDir()
+		}
+		repo, worktree, newBranch, err = CreateWorktree(workingDir, opts.Worktree, dir)
+		if err != nil {
+			return "", err
+		}
+		workingDir = worktree
+		cfg.WorkingDirectory = worktree
+		for w := range cfg.Windows {
+			for p := range cfg.Windows[w].Panes {
+				cfg.Windows[w].Panes[p].WorkingDirectory = worktree
+			}
+		}
+	}
 
 	if err := tmux.CreateTmuxSession(cfg); err != nil {
+		if worktree != "" {
+			if rmErr := RemoveWorktree(repo, worktree); rmErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not remove worktree %s: %v\n", worktree, rmErr)
+			} else if newBranch {
+				if brErr := DeleteBranch(repo, opts.Worktree); brErr != nil {
+					fmt.Fprintf(os.Stderr, "Warning: could not delete branch %s: %v\n", opts.Worktree, brErr)
+				}
+			}
+		}
 		return "", fmt.Errorf("could not create tmux session: %w", err)
 	}
 
 	now := time.Now()
 	if err := registry.AddEntry(registry.Entry{
-		Name:       name,
-		Type:       registry.TypeThrowaway,
-		CreatedAt:  now,
-		LastActive: now,
-		WorkingDir: workingDir,
-		Windows:    numWindows,
-		Terminal:   cfg.Terminal,
+		Name:         name,
+		Type:         registry.TypeThrowaway,
+		CreatedAt:    now,
+		LastActive:   now,
+		WorkingDir:   workingDir,
+		Windows:      opts.Windows,
+		Terminal:     cfg.Terminal,
+		TTL:          formatLimit(opts.TTL),
+		Idle:         formatLimit(opts.Idle),
+		WorktreePath: worktree,
+		WorktreeRepo: repo,
 	}); err != nil {
 		// Registry failure is a warning, not fatal — session was already created
 		fmt.Fprintf(os.Stderr, "Warning: could not register session in registry: %v\n", err)
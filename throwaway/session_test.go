@@ -50,6 +50,20 @@ func TestDetectShell_Fish(t *testing.T) {
 	}
 }
 
+func TestDetectShell_Nu(t *testing.T) {
+	t.Setenv("SHELL", "/opt/homebrew/bin/nu")
+	if got := tmux.DetectShell(); got != "nu" {
+		t.Fatalf("DetectShell() = %q, want 'nu'", got)
+	}
+}
+
+func TestDetectShell_Dash(t *testing.T) {
+	t.Setenv("SHELL", "/bin/dash")
+	if got := tmux.DetectShell(); got != "dash" {
+		t.Fatalf("DetectShell() = %q, want 'dash'", got)
+	}
+}
+
 func TestBuildThrowawayConfig_Defaults(t *testing.T) {
 	cfg, err := BuildThrowawayConfig("tw-test", "/tmp", DefaultWindows, DefaultPanesPerWindow)
 	if err != nil {
@@ -1,6 +1,22 @@
 package throwaway
 
+import "time"
+
 const (
 	DefaultWindows        = 2
 	DefaultPanesPerWindow = 2
 )
+
+// Options describes a throwaway session to create.
+type Options struct {
+	Name           string // auto-generated when empty
+	WorkingDir     string // defaults to the current directory
+	Windows        int
+	PanesPerWindow int
+	Layout         string        // grid, tiled, main-left or stacked; empty for side-by-side
+	Commands       []string      // pane commands in order; "" leaves a plain shell
+	TTL            time.Duration // kill after this long regardless of use; 0 = never
+	Idle           time.Duration // kill after this long without tmux activity; 0 = never
+	Worktree       string        // branch to check out in a new git worktree; the session starts there
+	WorktreeDir    string        // parent directory for the worktree; DefaultWorktreeDir() when empty
+}
@@ -0,0 +1,127 @@
+package throwaway
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// unsafeNameChars matches characters not allowed in session names or
+// worktree directory names.
+var unsafeNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// WorktreeError reports a worktree that could not be created: not a git
+// repository, an existing directory, or git refusing the branch. These are
+// the user's to fix rather than crashes.
+type WorktreeError struct {
+	Err error
+}
+
+func (e *WorktreeError) Error() string { return e.Err.Error() }
+func (e *WorktreeError) Unwrap() error { return e.Err }
+
+// DefaultWorktreeDir is where worktrees go when -worktree-dir is not given.
+func DefaultWorktreeDir() string {
+	return filepath.Join(os.TempDir(), "dolly-worktrees")
+}
+
+// WorktreeSessionName derives a session name from a branch, e.g.
+// "feature/login" → "wt-feature-login".
+func WorktreeSessionName(branch string) string {
+	return "wt-" + strings.Trim(unsafeNameChars.ReplaceAllString(branch, "-"), "-")
+}
+
+// git runs a git command in dir and returns its trimmed stdout. On failure
+// the error carries git's own message.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CreateWorktree adds a git worktree for branch under baseDir and returns
+// the repository root and the worktree path. An existing local branch is
+// checked out; a branch that exists on exactly one remote is tracked;
+// otherwise a new branch is started from HEAD. newBranch reports whether the
+// local branch was created here, so a rollback knows to delete it.
+func CreateWorktree(repoDir, branch, baseDir string) (repo, path string, newBranch bool, err error) {
+	defer func() {
+		if err != nil {
+			err = &WorktreeError{Err: err}
+		}
+	}()
+	if branch == "" || strings.HasPrefix(branch, "-") {
+		return "", "", false, fmt.Errorf("invalid branch name %q", branch)
+	}
+	repo, err = git(repoDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", "", false, fmt.Errorf("%s is not inside a git repository", repoDir)
+	}
+
+	path = filepath.Join(baseDir, filepath.Base(repo)+"-"+strings.TrimPrefix(WorktreeSessionName(branch), "wt-"))
+	if _, err := os.Stat(path); err == nil {
+		return "", "", false, fmt.Errorf("worktree directory %s already exists", path)
+	}
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return "", "", false, fmt.Errorf("could not create worktree directory: %w", err)
+	}
+
+	var args []string
+	switch {
+	case refExists(repo, "refs/heads/"+branch):
+		args = []string{"worktree", "add", path, branch}
+	default:
+		remotes, _ := git(repo, "for-each-ref", "--format=%(refname:short)", "refs/remotes/*/"+branch)
+		if lines := strings.Fields(remotes); len(lines) == 1 {
+			args = []string{"worktree", "add", "--track", "-b", branch, path, lines[0]}
+		} else {
+			args = []string{"worktree", "add", "-b", branch, path}
+		}
+		newBranch = true
+	}
+	if _, err := git(repo, args...); err != nil {
+		return "", "", false, err
+	}
+	return repo, path, newBranch, nil
+}
+
+func refExists(repo, ref string) bool {
+	_, err := git(repo, "rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
+
+// WorktreeDirty reports whether a worktree has uncommitted or untracked changes.
+func WorktreeDirty(path string) (bool, error) {
+	out, err := git(path, "status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
+// RemoveWorktree removes a clean worktree. git itself refuses when the
+// worktree has changes, so nothing uncommitted is ever deleted here.
+func RemoveWorktree(repo, path string) error {
+	_, err := git(repo, "worktree", "remove", path)
+	return err
+}
+
+// DeleteBranch force-deletes a local branch. It is only used to roll back a
+// branch CreateWorktree just started, which holds no commits of its own.
+func DeleteBranch(repo, branch string) error {
+	_, err := git(repo, "branch", "-D", branch)
+	return err
+}
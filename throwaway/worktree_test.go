@@ -0,0 +1,188 @@
+package throwaway
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates a git repository with one commit and returns its path.
+// Tests using it are skipped when git is not installed.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.email=t@example.com", "-c", "user.name=t", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		if _, err := git(dir, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	return dir
+}
+
+func currentBranch(t *testing.T, path string) string {
+	t.Helper()
+	out, err := git(path, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// ─── Naming ──────────────────────────────────────────────────────────────────
+
+func TestWorktreeSessionName(t *testing.T) {
+	for branch, want := range map[string]string{
+		"main":             "wt-main",
+		"feature/login":    "wt-feature-login",
+		"fix/#42 crash":    "wt-fix-42-crash",
+		"release/v1.2.0/":  "wt-release-v1-2-0",
+		"user.name/topic_": "wt-user-name-topic_",
+	} {
+		if got := WorktreeSessionName(branch); got != want {
+			t.Errorf("WorktreeSessionName(%q) = %q, want %q", branch, got, want)
+		}
+		if !validName.MatchString(WorktreeSessionName(branch)) {
+			t.Errorf("WorktreeSessionName(%q) is not a valid session name", branch)
+		}
+	}
+}
+
+// ─── CreateWorktree ──────────────────────────────────────────────────────────
+
+func TestCreateWorktreeNewBranch(t *testing.T) {
+	repo := newTestRepo(t)
+	base := t.TempDir()
+
+	gotRepo, path, newBranch, err := CreateWorktree(repo, "feature/login", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := filepath.EvalSymlinks(repo); gotRepo != want {
+		t.Errorf("repo = %q, want %q", gotRepo, want)
+	}
+	if want := filepath.Join(base, "repo-feature-login"); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if b := currentBranch(t, path); b != "feature/login" {
+		t.Errorf("worktree is on %q, want feature/login", b)
+	}
+	if !newBranch {
+		t.Error("a branch started from HEAD should be reported as new")
+	}
+
+	// Rolling back removes the worktree and the branch it started
+	if err := RemoveWorktree(gotRepo, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteBranch(gotRepo, "feature/login"); err != nil {
+		t.Fatal(err)
+	}
+	if refExists(gotRepo, "refs/heads/feature/login") {
+		t.Error("branch left behind after rollback")
+	}
+}
+
+func TestCreateWorktreeExistingBranch(t *testing.T) {
+	repo := newTestRepo(t)
+	if _, err := git(repo, "branch", "existing"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, path, newBranch, err := CreateWorktree(repo, "existing", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newBranch {
+		t.Error("an existing branch must not be reported as new, or a rollback would delete it")
+	}
+	if b := currentBranch(t, path); b != "existing" {
+		t.Errorf("worktree is on %q, want existing", b)
+	}
+}
+
+func TestCreateWorktreeTracksRemoteBranch(t *testing.T) {
+	origin := newTestRepo(t)
+	if _, err := git(origin, "branch", "remote-only"); err != nil {
+		t.Fatal(err)
+	}
+	clone := filepath.Join(t.TempDir(), "clone")
+	if _, err := git(origin, "clone", "-q", origin, clone); err != nil {
+		t.Fatal(err)
+	}
+
+	_, path, _, err := CreateWorktree(clone, "remote-only", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream, err := git(path, "rev-parse", "--abbrev-ref", "@{u}")
+	if err != nil || upstream != "origin/remote-only" {
+		t.Errorf("upstream = %q, %v; want origin/remote-only", upstream, err)
+	}
+}
+
+func TestCreateWorktreeErrors(t *testing.T) {
+	repo := newTestRepo(t)
+	base := t.TempDir()
+
+	var wtErr *WorktreeError
+	if _, _, _, err := CreateWorktree(t.TempDir(), "x", base); !errors.As(err, &wtErr) {
+		t.Errorf("outside a repository: got %v, want WorktreeError", err)
+	}
+	if _, _, _, err := CreateWorktree(repo, "-x", base); !errors.As(err, &wtErr) {
+		t.Errorf("option-like branch: got %v, want WorktreeError", err)
+	}
+
+	if _, _, _, err := CreateWorktree(repo, "dup", base); err != nil {
+		t.Fatal(err)
+	}
+	_, _, _, err := CreateWorktree(repo, "dup", base)
+	if !errors.As(err, &wtErr) || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("second worktree for the same branch: got %v", err)
+	}
+}
+
+// ─── Dirty check and removal ─────────────────────────────────────────────────
+
+func TestWorktreeDirtyAndRemove(t *testing.T) {
+	repo := newTestRepo(t)
+	gotRepo, path, _, err := CreateWorktree(repo, "scratch", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dirty, err := WorktreeDirty(path); err != nil || dirty {
+		t.Fatalf("fresh worktree: dirty = %v, %v", dirty, err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "notes.txt"), []byte("wip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if dirty, err := WorktreeDirty(path); err != nil || !dirty {
+		t.Fatalf("untracked file: dirty = %v, %v", dirty, err)
+	}
+
+	// git refuses to remove a worktree with changes
+	if err := RemoveWorktree(gotRepo, path); err == nil {
+		t.Fatal("RemoveWorktree should refuse a dirty worktree")
+	}
+	if err := os.Remove(filepath.Join(path, "notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveWorktree(gotRepo, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("worktree directory still exists after removal")
+	}
+}
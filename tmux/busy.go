@@ -0,0 +1,66 @@
+package tmux
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// BusyPane is a pane running something other than an idle shell.
+type BusyPane struct {
+	Window  string // window name
+	Pane    int    // pane index within the window
+	Command string // what the pane is running, e.g. "vim notes.md"
+}
+
+// String renders the pane as "window.pane  command".
+func (b BusyPane) String() string {
+	return fmt.Sprintf("%s.%d  %s", b.Window, b.Pane, b.Command)
+}
+
+// busyCommand is paneCommand with a fallback to the foreground program when
+// the pane's child process is missing or is itself a nested shell, so a busy
+// pane is never reported idle or as "bash".
+func busyCommand(startCommand, currentCommand, panePID string, procs processTable) string {
+	if isShellCommand(currentCommand) && (startCommand == "" || isShellCommand(strings.Trim(startCommand, `"`))) {
+		return ""
+	}
+	if cmd := paneCommand(startCommand, currentCommand, panePID, procs); cmd != "" && !isShellCommand(cmd) {
+		return cmd
+	}
+	return currentCommand
+}
+
+// BusyPanes lists the panes of a running session whose foreground process is
+// not an idle shell: editors, servers, migrations, ssh connections. A session
+// that is not running has no busy panes.
+func BusyPanes(name string) ([]BusyPane, error) {
+	if !IsSessionAlive(name) {
+		return nil, nil
+	}
+	cmd := exec.Command("tmux", "list-panes", "-s", "-t", "="+name, "-F",
+		"#{window_name}\t#{pane_index}\t#{pane_start_command}\t#{pane_current_command}\t#{pane_pid}")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list panes for %q: %w", name, err)
+	}
+
+	procs := readProcessTable()
+	var busy []BusyPane
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		f := strings.SplitN(line, "\t", 5)
+		if len(f) != 5 {
+			continue
+		}
+		command := busyCommand(f[2], f[3], f[4], procs)
+		if command == "" {
+			continue
+		}
+		index, _ := strconv.Atoi(f[1])
+		busy = append(busy, BusyPane{Window: f[0], Pane: index, Command: command})
+	}
+	return busy, nil
+}
@@ -0,0 +1,34 @@
+package tmux
+
+import "testing"
+
+func TestBusyCommand(t *testing.T) {
+	procs := processTable{
+		100: {"vim notes.md"},
+		200: {"bash"},
+	}
+
+	cases := []struct {
+		name                      string
+		start, current, pid, want string
+	}{
+		{"idle shell", "", "zsh", "100", ""},
+		{"idle shell with shell start command", `"zsh -l"`, "zsh", "100", ""},
+		{"foreground child", "", "vim", "100", "vim notes.md"},
+		{"pane started with a command", `"npm run dev"`, "node", "300", "npm run dev"},
+		{"nested shell falls back to foreground program", "", "vim", "200", "vim"},
+		{"child not found", "", "ssh", "300", "ssh"},
+	}
+	for _, c := range cases {
+		if got := busyCommand(c.start, c.current, c.pid, procs); got != c.want {
+			t.Errorf("%s: busyCommand = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestBusyPaneString(t *testing.T) {
+	b := BusyPane{Window: "editor", Pane: 1, Command: "vim notes.md"}
+	if got := b.String(); got != "editor.1  vim notes.md" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"tmux-manager/config"
+	"tmux-manager/shortcuts"
 )
 
 func shouldShowPaneLabels(cfg *config.TmuxConfig) bool {
@@ -23,16 +24,15 @@ func shouldShowPaneLabel(pane config.Pane, cfg *config.TmuxConfig) bool {
 	return shouldShowPaneLabels(cfg)
 }
 
-func setPaneLabel(sessionName, windowName string, paneIndex int, paneID string) error {
+func setPaneLabel(tmuxPaneID, paneID string) error {
 	if paneID == "" {
 		return nil
 	}
 
 	// Simple approach: just set the pane title to the pane ID
-	target := fmt.Sprintf("%s:%s.%d", sessionName, windowName, paneIndex)
-	cmd := exec.Command("tmux", "select-pane", "-t", target, "-T", paneID)
+	cmd := exec.Command("tmux", "select-pane", "-t", tmuxPaneID, "-T", paneID)
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to set pane label '%s' for pane %d: %w", paneID, paneIndex, err)
+		return fmt.Errorf("failed to set pane label '%s' for pane %s: %w", paneID, tmuxPaneID, err)
 	}
 	
 	return nil
@@ -47,34 +47,32 @@ func getPaneWorkingDir(pane config.Pane, fallbackDir string) string {
 	return fallbackDir
 }
 
-func injectShortcuts(sessionName, windowName string, paneIndex int, shortcutsFilePath string) error {
+func injectShortcuts(tmuxPaneID, shortcutsFilePath, terminal string) error {
 	if shortcutsFilePath == "" {
 		return nil
 	}
 
-	target := fmt.Sprintf("%s:%s.%d", sessionName, windowName, paneIndex)
-
-	cmd := exec.Command("tmux", "send-keys", "-t", target, fmt.Sprintf("source %s", shortcutsFilePath), "Enter")
+	cmd := exec.Command("tmux", "send-keys", "-t", tmuxPaneID, shortcuts.SourceCommand(terminal, shortcutsFilePath), "Enter")
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to source shortcuts file in pane %d: %w", paneIndex, err)
+		return fmt.Errorf("failed to source shortcuts file in pane %s: %w", tmuxPaneID, err)
 	}
 	time.Sleep(100 * time.Millisecond)
 
-	cmd = exec.Command("tmux", "send-keys", "-t", target, "clear", "Enter")
+	cmd = exec.Command("tmux", "send-keys", "-t", tmuxPaneID, "clear", "Enter")
 	cmd.Run()
 	time.Sleep(50 * time.Millisecond)
 
 	return nil
 }
 
-func executePreHooks(sessionName, windowName string, paneIndex int, preHooks []string, terminal string) error {
+func executePreHooks(tmuxPaneID string, preHooks []string, terminal string) error {
 	for _, hook := range preHooks {
 		if hook == "" {
 			continue
 		}
 
 		// Execute pre-hook command
-		cmd := exec.Command("tmux", "send-keys", "-t", fmt.Sprintf("%s:%s.%d", sessionName, windowName, paneIndex), hook, "Enter")
+		cmd := exec.Command("tmux", "send-keys", "-t", tmuxPaneID, hook, "Enter")
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to execute pre-hook '%s': %w", hook, err)
 		}
@@ -85,20 +83,24 @@ func executePreHooks(sessionName, windowName string, paneIndex int, preHooks []s
 	return nil
 }
 
-func executeCommand(sessionName, windowName string, paneIndex int, command string) error {
+func executeCommand(tmuxPaneID, command string) error {
 	if command == "" {
 		return nil
 	}
 
 	// Send the command to the shell in the pane (shell should already be initialized with profile)
-	cmd := exec.Command("tmux", "send-keys", "-t", fmt.Sprintf("%s:%s.%d", sessionName, windowName, paneIndex), command, "Enter")
+	cmd := exec.Command("tmux", "send-keys", "-t", tmuxPaneID, command, "Enter")
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to send command to pane %d: %w", paneIndex, err)
+		return fmt.Errorf("failed to send command to pane %s: %w", tmuxPaneID, err)
 	}
 	return nil
 }
 
-func SetupWindowPanes(sessionName, windowName string, panes []config.Pane, workingDir string, cfg *config.TmuxConfig) error {
+// SetupWindowPanes creates the configured panes inside an existing window.
+// firstTmuxPaneID is the %pane_id tmux reported when the window was created;
+// every later pane is addressed by the %pane_id returned from split-window, so
+// window names and pane-base-index never take part in targeting.
+func SetupWindowPanes(firstTmuxPaneID string, panes []config.Pane, workingDir string, cfg *config.TmuxConfig) error {
 	if len(panes) == 0 {
 		return nil
 	}
@@ -136,7 +138,7 @@ func SetupWindowPanes(sessionName, windowName string, panes []config.Pane, worki
 	// Create panes in order, but handle split_from logic
 	createdPanes := make(map[string]string) // pane ID to tmux pane ID (%xxx format)
 
-	// Always create the first pane - get its tmux pane ID
+	// The first pane already exists - it was created along with the window
 	firstPane := panes[0]
 	firstPaneID := firstPane.ID
 	if firstPaneID == "" {
@@ -144,28 +146,23 @@ func SetupWindowPanes(sessionName, windowName string, panes []config.Pane, worki
 	}
 
 	// Inject shortcuts, then execute first pane commands
-	if err := injectShortcuts(sessionName, windowName, 0, cfg.ShortcutsFilePath); err != nil {
+	if err := injectShortcuts(firstTmuxPaneID, cfg.ShortcutsFilePath, cfg.Terminal); err != nil {
 		return fmt.Errorf("failed to inject shortcuts for first pane: %w", err)
 	}
-	if err := executePreHooks(sessionName, windowName, 0, firstPane.PreHooks, cfg.Terminal); err != nil {
+	if err := executePreHooks(firstTmuxPaneID, firstPane.PreHooks, cfg.Terminal); err != nil {
 		return fmt.Errorf("failed to execute pre-hooks for first pane: %w", err)
 	}
-	if err := executeCommand(sessionName, windowName, 0, firstPane.Command); err != nil {
+	if err := executeCommand(firstTmuxPaneID, firstPane.Command); err != nil {
 		return fmt.Errorf("failed to execute command for first pane: %w", err)
 	}
 
 	// Set pane label for first pane if enabled and ID is explicitly provided
 	if firstPane.ID != "" && shouldShowPaneLabel(firstPane, cfg) {
-		if err := setPaneLabel(sessionName, windowName, 0, firstPaneID); err != nil {
+		if err := setPaneLabel(firstTmuxPaneID, firstPaneID); err != nil {
 			return fmt.Errorf("failed to set label for first pane: %w", err)
 		}
 	}
 
-	// Get the actual tmux pane ID for the first pane
-	firstTmuxPaneID, err := getTmuxPaneID(sessionName, windowName, 0)
-	if err != nil {
-		return fmt.Errorf("failed to get tmux pane ID for first pane: %w", err)
-	}
 	createdPanes[firstPaneID] = firstTmuxPaneID
 
 	// Create remaining panes
@@ -205,25 +202,19 @@ func SetupWindowPanes(sessionName, windowName string, panes []config.Pane, worki
 
 		createdPanes[paneID] = newTmuxPaneID
 
-		// Execute commands for the new pane - we need to find its index
-		newPaneIndex, err := getTmuxPaneIndex(newTmuxPaneID)
-		if err != nil {
-			return fmt.Errorf("failed to get index for new pane '%s': %w", paneID, err)
-		}
-
-		if err := injectShortcuts(sessionName, windowName, newPaneIndex, cfg.ShortcutsFilePath); err != nil {
+		if err := injectShortcuts(newTmuxPaneID, cfg.ShortcutsFilePath, cfg.Terminal); err != nil {
 			return fmt.Errorf("failed to inject shortcuts for pane '%s': %w", paneID, err)
 		}
-		if err := executePreHooks(sessionName, windowName, newPaneIndex, pane.PreHooks, cfg.Terminal); err != nil {
+		if err := executePreHooks(newTmuxPaneID, pane.PreHooks, cfg.Terminal); err != nil {
 			return fmt.Errorf("failed to execute pre-hooks for pane '%s': %w", paneID, err)
 		}
-		if err := executeCommand(sessionName, windowName, newPaneIndex, pane.Command); err != nil {
+		if err := executeCommand(newTmuxPaneID, pane.Command); err != nil {
 			return fmt.Errorf("failed to execute command for pane '%s': %w", paneID, err)
 		}
 
 		// Set pane label if enabled and ID is explicitly provided
 		if pane.ID != "" && shouldShowPaneLabel(pane, cfg) {
-			if err := setPaneLabel(sessionName, windowName, newPaneIndex, paneID); err != nil {
+			if err := setPaneLabel(newTmuxPaneID, paneID); err != nil {
 				return fmt.Errorf("failed to set label for pane '%s': %w", paneID, err)
 			}
 		}
@@ -232,29 +223,6 @@ func SetupWindowPanes(sessionName, windowName string, panes []config.Pane, worki
 	return nil
 }
 
-func getTmuxPaneID(sessionName, windowName string, paneIndex int) (string, error) {
-	cmd := exec.Command("tmux", "display-message", "-t", fmt.Sprintf("%s:%s.%d", sessionName, windowName, paneIndex), "-p", "#{pane_id}")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("failed to get tmux pane ID: %w", err)
-	}
-	return strings.TrimSpace(string(output)), nil
-}
-
-func getTmuxPaneIndex(paneID string) (int, error) {
-	cmd := exec.Command("tmux", "display-message", "-t", paneID, "-p", "#{pane_index}")
-	output, err := cmd.Output()
-	if err != nil {
-		return -1, fmt.Errorf("failed to get tmux pane index: %w", err)
-	}
-	indexStr := strings.TrimSpace(string(output))
-	var index int
-	if _, err := fmt.Sscanf(indexStr, "%d", &index); err != nil {
-		return -1, fmt.Errorf("failed to parse pane index '%s': %w", indexStr, err)
-	}
-	return index, nil
-}
-
 func createSplitPaneWithID(splitFromTmuxID string, pane config.Pane, workingDir, terminal string) (string, error) {
 	// Determine split direction
 	var splitFlag string
@@ -8,14 +8,16 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	"tmux-manager/config"
 	"tmux-manager/shortcuts"
 )
 
 // IsSessionAlive reports whether a tmux session with the given name is running.
+// The name is matched exactly; plain -t targets would also accept a prefix.
 func IsSessionAlive(name string) bool {
-	cmd := exec.Command("tmux", "has-session", "-t", name)
+	cmd := exec.Command("tmux", "has-session", "-t", "="+name)
 	cmd.Stdout = io.Discard
 	cmd.Stderr = io.Discard
 	return cmd.Run() == nil
@@ -40,12 +42,125 @@ func ListSessions() ([]string, error) {
 	return names, nil
 }
 
+// LiveSessions returns the names of all running tmux sessions as a set, from
+// a single list-sessions call. Use it instead of IsSessionAlive when checking
+// many names at once.
+func LiveSessions() map[string]bool {
+	names, _ := ListSessions()
+	live := make(map[string]bool, len(names))
+	for _, n := range names {
+		live[n] = true
+	}
+	return live
+}
+
+// SessionActivity is the attach state and last activity of a running session.
+type SessionActivity struct {
+	Clients  int       // number of attached clients (#{session_attached})
+	Activity time.Time // last input or output in the session (#{session_activity})
+}
+
+// ListSessionActivity returns the activity of every running session from a
+// single list-sessions call. Returns an empty map when no server is running.
+func ListSessionActivity() map[string]SessionActivity {
+	cmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}\t#{session_attached}\t#{session_activity}")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	activity := make(map[string]SessionActivity)
+	if err != nil {
+		return activity
+	}
+	for _, line := range strings.Split(string(bytes.TrimSpace(out)), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 || fields[0] == "" {
+			continue
+		}
+		var a SessionActivity
+		a.Clients, _ = strconv.Atoi(fields[1])
+		if secs, err := strconv.ParseInt(fields[2], 10, 64); err == nil && secs > 0 {
+			a.Activity = time.Unix(secs, 0)
+		}
+		activity[fields[0]] = a
+	}
+	return activity
+}
+
+// usageHookIndex is the slot dolly uses in the client-attached and
+// client-detached hook arrays, chosen high so user hooks at [0] are untouched.
+const usageHookIndex = 90
+
+// InstallUsageHooks registers global tmux hooks that run "dolly stats sample"
+// whenever a client attaches or detaches, so attached time is recorded
+// without a background daemon. Re-installing overwrites dolly's own slot.
+func InstallUsageHooks(dollyPath string) error {
+	sample := fmt.Sprintf("run-shell -b \"%s stats sample\"", shellQuote(dollyPath))
+	for _, hook := range []string{"client-attached", "client-detached", "session-closed"} {
+		name := fmt.Sprintf("%s[%d]", hook, usageHookIndex)
+		cmd := exec.Command("tmux", "set-hook", "-g", name, sample)
+		cmd.Stderr = io.Discard
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("could not install %s hook: %w", hook, err)
+		}
+	}
+	return nil
+}
+
+// UninstallUsageHooks removes the hooks InstallUsageHooks registered. Hooks
+// at other slots are left alone. Without running sessions there is no
+// server, and so no hooks to remove.
+func UninstallUsageHooks() error {
+	if len(ListSessionActivity()) == 0 {
+		return nil
+	}
+	for _, hook := range []string{"client-attached", "client-detached", "session-closed"} {
+		name := fmt.Sprintf("%s[%d]", hook, usageHookIndex)
+		cmd := exec.Command("tmux", "set-hook", "-gu", name)
+		cmd.Stderr = io.Discard
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("could not remove %s hook: %w", hook, err)
+		}
+	}
+	return nil
+}
+
+// ProtectedOption is the session user option marking a session dolly must
+// not kill without -force. It travels with the tmux session, so the marker
+// survives even when the registry entry is lost or the session is unmanaged.
+const ProtectedOption = "@dolly_protected"
+
+// SetProtected sets or clears the protection marker on a running session.
+func SetProtected(name string, protected bool) error {
+	args := []string{"set-option", "-t", "=" + name + ":", ProtectedOption, "1"}
+	if !protected {
+		args = []string{"set-option", "-u", "-t", "=" + name + ":", ProtectedOption}
+	}
+	cmd := exec.Command("tmux", args...)
+	cmd.Stderr = io.Discard
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not mark session %q: %w", name, err)
+	}
+	return nil
+}
+
+// IsProtected reports whether a running session carries the protection marker.
+func IsProtected(name string) bool {
+	cmd := exec.Command("tmux", "show-options", "-v", "-t", "="+name+":", ProtectedOption)
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	return err == nil && strings.TrimSpace(string(out)) == "1"
+}
+
+// shellQuote wraps s in single quotes for /bin/sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 // GetSessionDetails queries tmux for the window count and active pane's working
 // directory for the named session. On failure it returns zero values and an
 // error — callers should warn and continue rather than abort.
 func GetSessionDetails(name string) (windows int, workingDir string, err error) {
 	runQuery := func(format string) (string, error) {
-		cmd := exec.Command("tmux", "display-message", "-t", name, "-p", format)
+		cmd := exec.Command("tmux", "display-message", "-t", "="+name+":", "-p", format)
 		cmd.Stderr = io.Discard
 		out, e := cmd.Output()
 		return strings.TrimSpace(string(out)), e
@@ -82,13 +197,11 @@ func getDefaultLabelColor(cfg *config.TmuxConfig) string {
 	return "blue"
 }
 
-func enablePaneBordersForWindow(sessionName, windowName string, cfg *config.TmuxConfig) error {
-	windowTarget := fmt.Sprintf("%s:%s", sessionName, windowName)
-
+func enablePaneBordersForWindow(windowID string, cfg *config.TmuxConfig) error {
 	// Enable pane border status for this specific window
-	cmd := exec.Command("tmux", "set-window-option", "-t", windowTarget, "pane-border-status", "top")
+	cmd := exec.Command("tmux", "set-window-option", "-t", windowID, "pane-border-status", "top")
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to enable pane border status for window %s: %w", windowName, err)
+		return fmt.Errorf("failed to enable pane border status for window %s: %w", windowID, err)
 	}
 
 	// Simple colored format - just background color and the pane title
@@ -96,9 +209,9 @@ func enablePaneBordersForWindow(sessionName, windowName string, cfg *config.Tmux
 	simpleFormat := fmt.Sprintf("#[bg=%s,fg=white,bold] #{pane_title} #[default]", defaultColor)
 
 	// Set pane border format for this specific window
-	cmd = exec.Command("tmux", "set-window-option", "-t", windowTarget, "pane-border-format", simpleFormat)
+	cmd = exec.Command("tmux", "set-window-option", "-t", windowID, "pane-border-format", simpleFormat)
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to set pane border format for window %s: %w", windowName, err)
+		return fmt.Errorf("failed to set pane border format for window %s: %w", windowID, err)
 	}
 
 	return nil
@@ -137,39 +250,109 @@ func shouldUseAutoColor(cfg *config.TmuxConfig) bool {
 	return *cfg.AutoColor
 }
 
-func setWindowColor(sessionName, windowName, color string) error {
+func setWindowColor(windowID, color string) error {
 	if color == "" {
 		return nil
 	}
 
 	// Set the window tab background color in the status bar
 	// This colors the "1:development", "2:monitoring" etc tabs at the bottom
-	windowTarget := fmt.Sprintf("%s:%s", sessionName, windowName)
-
-	// Set window status format with colored background
-	cmd := exec.Command("tmux", "set-window-option", "-t", windowTarget, "window-status-style", fmt.Sprintf("bg=%s,fg=black", color))
+	cmd := exec.Command("tmux", "set-window-option", "-t", windowID, "window-status-style", fmt.Sprintf("bg=%s,fg=black", color))
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to set window tab color '%s' for window %s: %w", color, windowName, err)
+		return fmt.Errorf("failed to set window tab color '%s' for window %s: %w", color, windowID, err)
 	}
 
 	// Also set the current window style to make it more visible when selected
-	cmd = exec.Command("tmux", "set-window-option", "-t", windowTarget, "window-status-current-style", fmt.Sprintf("bg=bright%s,fg=black,bold", color))
+	cmd = exec.Command("tmux", "set-window-option", "-t", windowID, "window-status-current-style", fmt.Sprintf("bg=bright%s,fg=black,bold", color))
 	if err := cmd.Run(); err != nil {
 		// If bright version fails, just use regular color
-		exec.Command("tmux", "set-window-option", "-t", windowTarget, "window-status-current-style", fmt.Sprintf("bg=%s,fg=white,bold", color)).Run()
+		exec.Command("tmux", "set-window-option", "-t", windowID, "window-status-current-style", fmt.Sprintf("bg=%s,fg=white,bold", color)).Run()
 	}
 
 	return nil
 }
 
+// applyWindowLayout runs select-layout with either a preset name (tiled,
+// main-vertical, …) or a full layout string captured by SnapshotSession.
+func applyWindowLayout(windowID, layout string) error {
+	if layout == "" {
+		return nil
+	}
+	proportionalMainPane(windowID, layout)
+	cmd := exec.Command("tmux", "select-layout", "-t", windowID, layout)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to apply layout to window %s: %w (output: %s)", windowID, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// proportionalMainPane gives main-vertical/main-horizontal a main pane of
+// 60% when the user still has tmux's fixed default (80 columns / 24 rows).
+// Sessions are created detached at 80x24, so the fixed default would leave
+// the other panes no room at all. Best-effort: tmux before 3.2 rejects
+// percentages and keeps its own behaviour.
+func proportionalMainPane(windowID, layout string) {
+	option, fixedDefault := "", ""
+	switch layout {
+	case "main-vertical":
+		option, fixedDefault = "main-pane-width", "80"
+	case "main-horizontal":
+		option, fixedDefault = "main-pane-height", "24"
+	default:
+		return
+	}
+	out, err := exec.Command("tmux", "show-options", "-gwv", option).Output()
+	if err != nil || strings.TrimSpace(string(out)) != fixedDefault {
+		return
+	}
+	exec.Command("tmux", "set-option", "-w", "-t", windowID, option, "60%").Run()
+}
+
+// windowIDsFormat makes new-session/new-window print the IDs of the window and
+// its first pane. Everything after creation is targeted through these IDs so
+// duplicate window names, names containing '.' or ':', and a non-zero
+// pane-base-index cannot send commands to the wrong place.
+const windowIDsFormat = "#{window_id} #{pane_id}"
+
+// parseWindowIDs splits the "@window_id %pane_id" line printed by -P -F.
+func parseWindowIDs(output []byte) (windowID, paneID string, err error) {
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 || !strings.HasPrefix(fields[0], "@") || !strings.HasPrefix(fields[1], "%") {
+		return "", "", fmt.Errorf("unexpected tmux output %q", strings.TrimSpace(string(output)))
+	}
+	return fields[0], fields[1], nil
+}
+
+// WarnShortcutPacks prints one warning per problem LoadPacks or ReadPacks
+// reported; the other packs still load.
+func WarnShortcutPacks(err error) {
+	for _, line := range strings.Split(err.Error(), "\n") {
+		fmt.Fprintf(os.Stderr, "Warning: shortcut packs: %s\n", line)
+	}
+}
+
 func CreateTmuxSession(cfg *config.TmuxConfig) error {
-	// Merge shortcut layers: defaults <- global <- per-session
-	globalSC, _ := shortcuts.LoadGlobal()
-	var defaults map[string]string
+	// Merge shortcut layers: defaults <- global <- project <- per-session,
+	// keeping only the groups the config selects
+	filter := shortcuts.GroupFilter{Include: cfg.ShortcutGroups, Exclude: cfg.ExcludeShortcutGroups}
+	userGroups, _ := shortcuts.LoadGroups()
+	packs, err := shortcuts.LoadPacks()
+	if err != nil {
+		WarnShortcutPacks(err)
+	}
+	for _, g := range filter.Unknown(userGroups, packs) {
+		fmt.Fprintf(os.Stderr, "Warning: unknown shortcut group %q\n", g)
+	}
+	globalSC, _ := shortcuts.LoadGlobalLayer(filter, packs)
+	var defaults map[string]shortcuts.Shortcut
 	if cfg.DefaultShortcuts == nil || *cfg.DefaultShortcuts {
-		defaults = shortcuts.DefaultShortcuts
+		defaults = shortcuts.SelectDefaults(filter)
 	}
-	merged := shortcuts.Merge(defaults, globalSC, cfg.Shortcuts)
+	projectSC, _, err := shortcuts.LoadProject(cfg.WorkingDirectory)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring project shortcuts: %v\n", err)
+	}
+	merged := shortcuts.Merge(defaults, globalSC, projectSC, cfg.Shortcuts)
 	cfg.Shortcuts = merged
 
 	if len(cfg.Shortcuts) > 0 {
@@ -182,7 +365,7 @@ func CreateTmuxSession(cfg *config.TmuxConfig) error {
 	}
 
 	// Kill existing session if it exists
-	exec.Command("tmux", "kill-session", "-t", cfg.SessionName).Run()
+	exec.Command("tmux", "kill-session", "-t", "="+cfg.SessionName).Run()
 
 	// Set base-index to 1 so window numbering starts from 1
 	exec.Command("tmux", "set-option", "-g", "base-index", "1").Run()
@@ -205,24 +388,34 @@ func CreateTmuxSession(cfg *config.TmuxConfig) error {
 	shellCmd := GetShellCommand(cfg.Terminal)
 
 	if firstPaneWorkingDir != "" {
-		cmd = exec.Command("tmux", "new-session", "-d", "-s", cfg.SessionName, "-n", firstWindow.Name, "-c", firstPaneWorkingDir, shellCmd)
+		cmd = exec.Command("tmux", "new-session", "-d", "-s", cfg.SessionName, "-n", firstWindow.Name, "-c", firstPaneWorkingDir, "-P", "-F", windowIDsFormat, shellCmd)
 	} else {
-		cmd = exec.Command("tmux", "new-session", "-d", "-s", cfg.SessionName, "-n", firstWindow.Name, shellCmd)
+		cmd = exec.Command("tmux", "new-session", "-d", "-s", cfg.SessionName, "-n", firstWindow.Name, "-P", "-F", windowIDsFormat, shellCmd)
 	}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create tmux session: %w", err)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to create tmux session: %w (output: %s)", err, strings.TrimSpace(stderr.String()))
+	}
+	firstWindowID, firstPaneID, err := parseWindowIDs(output)
+	if err != nil {
+		return fmt.Errorf("failed to read IDs of first window: %w", err)
 	}
 
 	// Setup panes for first window
-	err := SetupWindowPanes(cfg.SessionName, firstWindow.Name, firstWindow.Panes, cfg.WorkingDirectory, cfg)
+	err = SetupWindowPanes(firstPaneID, firstWindow.Panes, cfg.WorkingDirectory, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to setup panes for first window: %w", err)
 	}
+	if err := applyWindowLayout(firstWindowID, firstWindow.Layout); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 
 	// Enable pane borders for first window if labels are enabled
 	if shouldShowPaneLabelsGlobal(cfg) {
-		err := enablePaneBordersForWindow(cfg.SessionName, firstWindow.Name, cfg)
+		err := enablePaneBordersForWindow(firstWindowID, cfg)
 		if err != nil {
 			return fmt.Errorf("failed to enable pane borders for first window: %w", err)
 		}
@@ -235,14 +428,12 @@ func CreateTmuxSession(cfg *config.TmuxConfig) error {
 		color = getAutoColor(globalWindowIndex)
 	}
 	globalWindowIndex++
-	if err := setWindowColor(cfg.SessionName, firstWindow.Name, color); err != nil {
+	if err := setWindowColor(firstWindowID, color); err != nil {
 		return fmt.Errorf("failed to set color for first window: %w", err)
 	}
 
 	// Create additional windows
-	for i, window := range cfg.Windows[1:] {
-		windowIndex := i + 1
-
+	for _, window := range cfg.Windows[1:] {
 		// Create new window with working directory (use first pane's dir if specified, otherwise session's dir)
 		windowWorkingDir := cfg.WorkingDirectory
 		if len(window.Panes) > 0 && window.Panes[0].WorkingDirectory != "" {
@@ -252,24 +443,34 @@ func CreateTmuxSession(cfg *config.TmuxConfig) error {
 		// Use session: format to avoid ambiguity when session name matches a window name
 		sessionTarget := cfg.SessionName + ":"
 		if windowWorkingDir != "" {
-			cmd = exec.Command("tmux", "new-window", "-t", sessionTarget, "-n", window.Name, "-c", windowWorkingDir, shellCmd)
+			cmd = exec.Command("tmux", "new-window", "-t", sessionTarget, "-n", window.Name, "-c", windowWorkingDir, "-P", "-F", windowIDsFormat, shellCmd)
 		} else {
-			cmd = exec.Command("tmux", "new-window", "-t", sessionTarget, "-n", window.Name, shellCmd)
+			cmd = exec.Command("tmux", "new-window", "-t", sessionTarget, "-n", window.Name, "-P", "-F", windowIDsFormat, shellCmd)
 		}
 
-		output, err := cmd.CombinedOutput()
+		// Only stdout holds the IDs; a warning on stderr must not break parsing
+		stderr.Reset()
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("failed to create window '%s': %w (output: %s)", window.Name, err, strings.TrimSpace(stderr.String()))
+		}
+		windowID, paneID, err := parseWindowIDs(output)
 		if err != nil {
-			return fmt.Errorf("failed to create window '%s': %w (output: %s)", window.Name, err, string(output))
+			return fmt.Errorf("failed to read IDs of window '%s': %w", window.Name, err)
 		}
 
-		err = SetupWindowPanes(cfg.SessionName, window.Name, window.Panes, cfg.WorkingDirectory, cfg)
+		err = SetupWindowPanes(paneID, window.Panes, cfg.WorkingDirectory, cfg)
 		if err != nil {
 			return fmt.Errorf("failed to setup panes for window '%s': %w", window.Name, err)
 		}
+		if err := applyWindowLayout(windowID, window.Layout); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
 
 		// Enable pane borders for this window if labels are enabled
 		if shouldShowPaneLabelsGlobal(cfg) {
-			err := enablePaneBordersForWindow(cfg.SessionName, window.Name, cfg)
+			err := enablePaneBordersForWindow(windowID, cfg)
 			if err != nil {
 				return fmt.Errorf("failed to enable pane borders for window '%s': %w", window.Name, err)
 			}
@@ -281,21 +482,27 @@ func CreateTmuxSession(cfg *config.TmuxConfig) error {
 			color = getAutoColor(globalWindowIndex)
 		}
 		globalWindowIndex++
-		if err := setWindowColor(cfg.SessionName, window.Name, color); err != nil {
+		if err := setWindowColor(windowID, color); err != nil {
 			return fmt.Errorf("failed to set color for window '%s': %w", window.Name, err)
 		}
 
 		// Select the first pane in the window
-		cmd = exec.Command("tmux", "select-pane", "-t", fmt.Sprintf("%s:%d.0", cfg.SessionName, windowIndex+1))
+		cmd = exec.Command("tmux", "select-pane", "-t", paneID)
 		cmd.Run()
 	}
 
 	// Select first window
-	cmd = exec.Command("tmux", "select-window", "-t", fmt.Sprintf("%s:1", cfg.SessionName))
+	cmd = exec.Command("tmux", "select-window", "-t", firstWindowID)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to select first window: %w", err)
 	}
 
+	if cfg.Protected {
+		if err := SetProtected(cfg.SessionName, true); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
 	// Add shell alias if RC file is configured
 	if cfg.RcFile != "" {
 		aliasName, err := AddShellAlias(cfg.RcFile, cfg.SessionName)
@@ -325,7 +532,7 @@ func TerminateTmuxSession(sessionName string, rcFile string) error {
 		}
 	}
 
-	cmd := exec.Command("tmux", "kill-session", "-t", sessionName)
+	cmd := exec.Command("tmux", "kill-session", "-t", "="+sessionName)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to terminate tmux session '%s': %w", sessionName, err)
 	}
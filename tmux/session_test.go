@@ -0,0 +1,27 @@
+package tmux
+
+import "testing"
+
+func TestParseWindowIDs(t *testing.T) {
+	tests := []struct {
+		output         string
+		windowID, pane string
+		wantErr        bool
+	}{
+		{"@1 %1\n", "@1", "%1", false},
+		{"@12 %34", "@12", "%34", false},
+		{"  @3   %7  \n", "@3", "%7", false},
+		{"", "", "", true},
+		{"@1\n", "", "", true},
+		{"%1 @1\n", "", "", true},
+		{"@1 %1 extra\n", "", "", true},
+		{"no server running on /tmp/tmux-0/default\n", "", "", true},
+	}
+	for _, tt := range tests {
+		w, p, err := parseWindowIDs([]byte(tt.output))
+		if (err != nil) != tt.wantErr || w != tt.windowID || p != tt.pane {
+			t.Errorf("parseWindowIDs(%q) = %q, %q, %v; want %q, %q, error %v",
+				tt.output, w, p, err, tt.windowID, tt.pane, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package tmux
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"tmux-manager/config"
+)
+
+// PaneSnapshot describes one live pane as observed by SnapshotSession.
+type PaneSnapshot struct {
+	WorkingDir string
+	Command    string // running program; empty when the pane sits at an idle shell
+	Title      string // empty when tmux still shows its default (the hostname)
+}
+
+// WindowSnapshot describes one live window and its panes, in pane order.
+type WindowSnapshot struct {
+	Name   string
+	Layout string // tmux layout string as reported by #{window_layout}
+	Panes  []PaneSnapshot
+}
+
+// SessionSnapshot is the window and pane structure of a running session.
+type SessionSnapshot struct {
+	Name    string
+	Windows []WindowSnapshot
+}
+
+// knownShells lists program names treated as "an idle shell" rather than a
+// command worth recording.
+var knownShells = map[string]bool{
+	"bash": true, "zsh": true, "fish": true, "sh": true, "dash": true,
+	"ksh": true, "tcsh": true, "csh": true, "nu": true,
+}
+
+// isShellCommand reports whether a command line just starts an interactive
+// shell (e.g. "zsh -l" or "-bash").
+func isShellCommand(cmdline string) bool {
+	fields := strings.Fields(cmdline)
+	if len(fields) == 0 {
+		return true
+	}
+	return knownShells[strings.TrimPrefix(filepath.Base(fields[0]), "-")]
+}
+
+// processTable maps a parent PID to the command lines of its children.
+type processTable map[int][]string
+
+// readProcessTable lists every process once via ps. The -A -o form works on
+// both Linux and macOS; failures yield an empty table so callers degrade to
+// "no child process".
+func readProcessTable() processTable {
+	cmd := exec.Command("ps", "-A", "-o", "pid=,ppid=,args=")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return processTable{}
+	}
+	table := processTable{}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		ppid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		table[ppid] = append(table[ppid], strings.Join(fields[2:], " "))
+	}
+	return table
+}
+
+// paneCommand decides what a pane is running: its start command when that is
+// not just a shell, nothing when the foreground process is the shell itself,
+// otherwise the first child process of the pane's shell.
+func paneCommand(startCommand, currentCommand, panePID string, procs processTable) string {
+	startCommand = strings.Trim(startCommand, `"`)
+	if startCommand != "" && !isShellCommand(startCommand) {
+		return startCommand
+	}
+	if isShellCommand(currentCommand) {
+		return ""
+	}
+	pid, err := strconv.Atoi(panePID)
+	if err != nil {
+		return ""
+	}
+	if children := procs[pid]; len(children) > 0 {
+		return children[0]
+	}
+	return ""
+}
+
+// SnapshotSession walks a running session's windows and panes with
+// list-windows/list-panes and returns names, layouts, pane working
+// directories, running commands and pane titles.
+func SnapshotSession(name string) (*SessionSnapshot, error) {
+	if !IsSessionAlive(name) {
+		return nil, fmt.Errorf("no tmux session named %q is currently running", name)
+	}
+
+	cmd := exec.Command("tmux", "list-windows", "-t", "="+name, "-F", "#{window_id}\t#{window_name}\t#{window_layout}")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list windows for %q: %w", name, err)
+	}
+
+	hostname, _ := os.Hostname()
+	procs := readProcessTable()
+	snap := &SessionSnapshot{Name: name}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		window := WindowSnapshot{Name: fields[1], Layout: fields[2]}
+
+		pcmd := exec.Command("tmux", "list-panes", "-t", fields[0], "-F",
+			"#{pane_current_path}\t#{pane_start_command}\t#{pane_current_command}\t#{pane_pid}\t#{pane_title}")
+		pcmd.Stderr = io.Discard
+		pout, err := pcmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("could not list panes for window %q: %w", window.Name, err)
+		}
+		for _, pline := range strings.Split(strings.TrimSpace(string(pout)), "\n") {
+			pf := strings.SplitN(pline, "\t", 5)
+			if len(pf) != 5 {
+				continue
+			}
+			title := pf[4]
+			if title == hostname {
+				title = ""
+			}
+			window.Panes = append(window.Panes, PaneSnapshot{
+				WorkingDir: pf[0],
+				Command:    paneCommand(pf[1], pf[2], pf[3], procs),
+				Title:      title,
+			})
+		}
+		snap.Windows = append(snap.Windows, window)
+	}
+
+	if len(snap.Windows) == 0 {
+		return nil, fmt.Errorf("session %q has no windows", name)
+	}
+	return snap, nil
+}
+
+// ConfigFromSnapshot converts a snapshot into a TmuxConfig that recreates the
+// same windows and panes. The session working directory is the first pane's
+// path; panes only carry working_directory when it differs. Each window keeps
+// its layout string so split directions don't need to be reconstructed.
+func ConfigFromSnapshot(snap *SessionSnapshot, terminal string) *config.TmuxConfig {
+	cfg := &config.TmuxConfig{
+		SessionName: snap.Name,
+		Terminal:    terminal,
+	}
+	if len(snap.Windows) > 0 && len(snap.Windows[0].Panes) > 0 {
+		cfg.WorkingDirectory = snap.Windows[0].Panes[0].WorkingDir
+	}
+
+	for _, w := range snap.Windows {
+		window := config.Window{Name: w.Name, Layout: w.Layout}
+		// Untitled panes get paneN at creation; keep titles clear of those
+		usedIDs := make(map[string]bool)
+		for i, p := range w.Panes {
+			if p.Title == "" {
+				usedIDs[fmt.Sprintf("pane%d", i+1)] = true
+			}
+		}
+		for i, p := range w.Panes {
+			pane := config.Pane{Command: p.Command, Split: "vertical"}
+			if i == 0 {
+				pane.Split = "none"
+			}
+			if p.WorkingDir != cfg.WorkingDirectory {
+				pane.WorkingDirectory = p.WorkingDir
+			}
+			// Titles become pane IDs (and therefore labels); repeats get -2, -3
+			if p.Title != "" {
+				pane.ID = p.Title
+				for n := 2; usedIDs[pane.ID]; n++ {
+					pane.ID = fmt.Sprintf("%s-%d", p.Title, n)
+				}
+				usedIDs[pane.ID] = true
+			}
+			window.Panes = append(window.Panes, pane)
+		}
+		cfg.Windows = append(cfg.Windows, window)
+	}
+	return cfg
+}
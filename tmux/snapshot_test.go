@@ -0,0 +1,101 @@
+package tmux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsShellCommand(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want bool
+	}{
+		{"zsh -l", true},
+		{"/bin/bash -l", true},
+		{"-zsh", true},
+		{"fish", true},
+		{"", true},
+		{"vim main.go", false},
+		{"npm run dev", false},
+	}
+	for _, tt := range tests {
+		if got := isShellCommand(tt.cmd); got != tt.want {
+			t.Errorf("isShellCommand(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestPaneCommand(t *testing.T) {
+	procs := processTable{100: {"htop -d 10"}}
+
+	// A non-shell start command wins
+	if got := paneCommand(`"npm run dev"`, "node", "100", procs); got != "npm run dev" {
+		t.Errorf("start command: got %q", got)
+	}
+	// A shell start command falls back to the first child process
+	if got := paneCommand(`"zsh -l"`, "htop", "100", procs); got != "htop -d 10" {
+		t.Errorf("child process: got %q", got)
+	}
+	// A shell in the foreground records nothing, even with background children
+	if got := paneCommand(`"zsh -l"`, "zsh", "100", procs); got != "" {
+		t.Errorf("idle shell: got %q, want empty", got)
+	}
+}
+
+func TestConfigFromSnapshot(t *testing.T) {
+	snap := &SessionSnapshot{
+		Name: "work",
+		Windows: []WindowSnapshot{
+			{Name: "edit", Layout: "tiled", Panes: []PaneSnapshot{
+				{WorkingDir: "/src", Command: "vim", Title: "editor"},
+				{WorkingDir: "/src/web", Command: "npm run dev", Title: "editor"},
+			}},
+			{Name: "edit", Panes: []PaneSnapshot{{WorkingDir: "/src"}}},
+		},
+	}
+
+	cfg := ConfigFromSnapshot(snap, "zsh")
+
+	if cfg.SessionName != "work" || cfg.Terminal != "zsh" || cfg.WorkingDirectory != "/src" {
+		t.Fatalf("unexpected session fields: %+v", cfg)
+	}
+	if len(cfg.Windows) != 2 {
+		t.Fatalf("expected 2 windows, got %d", len(cfg.Windows))
+	}
+	w := cfg.Windows[0]
+	if w.Layout != "tiled" {
+		t.Errorf("Layout = %q, want 'tiled'", w.Layout)
+	}
+	if w.Panes[0].Split != "none" || w.Panes[1].Split != "vertical" {
+		t.Errorf("unexpected splits: %q, %q", w.Panes[0].Split, w.Panes[1].Split)
+	}
+	if w.Panes[0].WorkingDirectory != "" {
+		t.Errorf("pane in session dir should omit working_directory, got %q", w.Panes[0].WorkingDirectory)
+	}
+	if w.Panes[1].WorkingDirectory != "/src/web" {
+		t.Errorf("pane WorkingDirectory = %q, want '/src/web'", w.Panes[1].WorkingDirectory)
+	}
+	// Duplicate titles get a numeric suffix
+	if w.Panes[0].ID != "editor" || w.Panes[1].ID != "editor-2" {
+		t.Errorf("unexpected IDs: %q, %q", w.Panes[0].ID, w.Panes[1].ID)
+	}
+}
+
+func TestConfigFromSnapshotUniqueIDs(t *testing.T) {
+	snap := &SessionSnapshot{Name: "work", Windows: []WindowSnapshot{{Name: "w", Panes: []PaneSnapshot{
+		{Title: "pane2"}, // would collide with the generated ID of the untitled second pane
+		{},
+		{Title: "logs"},
+		{Title: "logs"},
+		{Title: "logs-2"},
+	}}}}
+
+	got := []string{}
+	for _, p := range ConfigFromSnapshot(snap, "zsh").Windows[0].Panes {
+		got = append(got, p.ID)
+	}
+	want := []string{"pane2-2", "", "logs", "logs-2", "logs-2-2"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("IDs = %q, want %q", got, want)
+	}
+}
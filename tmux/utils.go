@@ -6,12 +6,13 @@ import (
 	"strings"
 )
 
-// DetectShell returns the user's current shell (zsh/fish/bash) from $SHELL,
-// falling back to "bash" when the variable is unset or unrecognised.
+// DetectShell returns the user's current shell (bash/zsh/fish/nu/sh/dash)
+// from $SHELL, falling back to "bash" when the variable is unset or
+// unrecognised.
 func DetectShell() string {
 	shell := filepath.Base(os.Getenv("SHELL"))
 	switch shell {
-	case "zsh", "fish", "bash":
+	case "zsh", "fish", "bash", "nu", "sh", "dash":
 		return shell
 	default:
 		return "bash"
@@ -27,6 +28,10 @@ func GetShellCommand(terminal string) string {
 		return "fish -l"
 	case "bash":
 		return "bash -l"
+	case "nu":
+		return "nu -l"
+	case "sh", "dash":
+		return strings.ToLower(terminal) + " -l"
 	default:
 		return terminal + " -l"
 	}
@@ -0,0 +1,369 @@
+// Package usage records how long tmux sessions are attached. Sampling is
+// driven by tmux hooks (client-attached, client-detached, session-closed)
+// that run "dolly stats sample"; each sample opens an interval for sessions
+// that gained a client and closes intervals for sessions that lost theirs.
+//
+// Tracking is opt-in: the hooks are only installed once `dolly stats enable`
+// has created ~/.dolly/usage-enabled. Open intervals live in
+// ~/.dolly/usage-open.json and closed ones are appended to
+// ~/.dolly/usage.jsonl. Sample performs an unlocked read-modify-write, so
+// callers serialize it (dolly runs it inside registry.Update).
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"tmux-manager/history"
+	"tmux-manager/internal/dolly"
+)
+
+// Interval is one stretch of time during which a session had a client attached.
+type Interval struct {
+	Session    string    `json:"session"`
+	ConfigFile string    `json:"config_file,omitempty"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+}
+
+// openInterval is an interval that has not ended yet. LastSeen is the latest
+// sample that found the session attached; it becomes the end when the
+// session vanishes between samples.
+type openInterval struct {
+	Interval
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Observation is what a sample sees of one live session.
+type Observation struct {
+	Attached   bool   // at least one client attached
+	ConfigFile string // from the registry, when known
+}
+
+type openState struct {
+	Open map[string]openInterval `json:"open"`
+}
+
+func dataPath(name string) (string, error) {
+	dir, err := dolly.DataDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine dolly data directory: %w", err)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// Enabled reports whether usage tracking has been turned on.
+func Enabled() bool {
+	path, err := dataPath("usage-enabled")
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// SetEnabled turns usage tracking on or off by creating or removing
+// ~/.dolly/usage-enabled. Recorded intervals are kept either way.
+func SetEnabled(on bool) error {
+	path, err := dataPath("usage-enabled")
+	if err != nil {
+		return err
+	}
+	if !on {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not disable usage tracking: %w", err)
+		}
+		return nil
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return fmt.Errorf("could not enable usage tracking: %w", err)
+	}
+	return nil
+}
+
+func loadOpen() (*openState, error) {
+	path, err := dataPath("usage-open.json")
+	if err != nil {
+		return nil, err
+	}
+	st := &openState{Open: map[string]openInterval{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read usage state: %w", err)
+	}
+	// A damaged state file only loses the currently open intervals
+	if json.Unmarshal(data, st) != nil || st.Open == nil {
+		st.Open = map[string]openInterval{}
+	}
+	return st, nil
+}
+
+func saveOpen(st *openState) error {
+	path, err := dataPath("usage-open.json")
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "usage-open-*.json.tmp")
+	if err != nil {
+		return fmt.Errorf("could not create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return fmt.Errorf("could not write usage state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("could not close temp file: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return fmt.Errorf("could not save usage state: %w", err)
+	}
+	return nil
+}
+
+func appendClosed(intervals []Interval) error {
+	if len(intervals) == 0 {
+		return nil
+	}
+	path, err := dataPath("usage.jsonl")
+	if err != nil {
+		return err
+	}
+	var buf []byte
+	for _, iv := range intervals {
+		line, err := json.Marshal(iv)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open usage log: %w", err)
+	}
+	_, werr := f.Write(buf)
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		return fmt.Errorf("could not write usage log: %w", werr)
+	}
+	return nil
+}
+
+// Sample compares the live sessions with the open intervals. Sessions that
+// are attached but not open start an interval at now. Open sessions that
+// are still alive but detached end at now; sessions that are gone end at
+// the last time they were seen alive, so a tmux server that died between
+// samples is not billed for the gap.
+func Sample(now time.Time, live map[string]Observation) error {
+	st, err := loadOpen()
+	if err != nil {
+		return err
+	}
+
+	var closed []Interval
+	for name, open := range st.Open {
+		obs, alive := live[name]
+		iv := open.Interval
+		switch {
+		case alive && obs.Attached:
+			open.LastSeen = now
+			st.Open[name] = open
+			continue
+		case alive:
+			iv.End = now
+		default:
+			iv.End = open.LastSeen
+		}
+		delete(st.Open, name)
+		if iv.End.After(iv.Start) {
+			closed = append(closed, iv)
+		}
+	}
+
+	for name, obs := range live {
+		if _, open := st.Open[name]; open || !obs.Attached {
+			continue
+		}
+		st.Open[name] = openInterval{
+			Interval: Interval{Session: name, ConfigFile: obs.ConfigFile, Start: now},
+			LastSeen: now,
+		}
+	}
+
+	if err := appendClosed(closed); err != nil {
+		return err
+	}
+	return saveOpen(st)
+}
+
+// CloseAll ends every open interval at the last time its session was seen
+// attached. Used when tracking is turned off, since no sample would close
+// them afterwards.
+func CloseAll(now time.Time) error {
+	return Sample(now, nil)
+}
+
+// Intervals returns every recorded interval overlapping [since, until],
+// clipped to that range. Open intervals end when their session was last seen
+// attached, so one left behind by a dead server or disabled hooks does not
+// keep accruing time; take a sample first to bring attached sessions up to
+// date. A zero since means "from the beginning".
+func Intervals(since, until time.Time) ([]Interval, error) {
+	var all []Interval
+
+	path, err := dataPath("usage.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not read usage log: %w", err)
+	}
+	if err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var iv Interval
+			if line := strings.TrimSpace(scanner.Text()); line != "" && json.Unmarshal([]byte(line), &iv) == nil {
+				all = append(all, iv)
+			}
+		}
+		serr := scanner.Err()
+		f.Close()
+		if serr != nil {
+			return nil, fmt.Errorf("could not read usage log: %w", serr)
+		}
+	}
+
+	st, err := loadOpen()
+	if err != nil {
+		return nil, err
+	}
+	for _, open := range st.Open {
+		iv := open.Interval
+		iv.End = open.LastSeen
+		all = append(all, iv)
+	}
+
+	return clip(all, since, until), nil
+}
+
+// clip trims intervals to [since, until] and drops those entirely outside.
+func clip(intervals []Interval, since, until time.Time) []Interval {
+	var out []Interval
+	for _, iv := range intervals {
+		if !since.IsZero() && iv.Start.Before(since) {
+			iv.Start = since
+		}
+		if iv.End.After(until) {
+			iv.End = until
+		}
+		if iv.End.After(iv.Start) {
+			out = append(out, iv)
+		}
+	}
+	return out
+}
+
+// Row is one line of the stats report.
+type Row struct {
+	Key      string
+	Attached time.Duration
+	Sessions int // distinct sessions with attached time
+	Created  int
+	Ended    int           // sessions whose lifetime ended in range
+	Lifetime time.Duration // average over Ended; zero when none
+}
+
+// removalActions end a session's lifetime.
+var removalActions = map[history.Action]bool{
+	history.ActionTerminate:  true,
+	history.ActionCleanup:    true,
+	history.ActionSyncRemove: true,
+}
+
+// Summarize groups attached time, creates and session lifetimes by keyOf
+// (e.g. config file or session name). events must be the full history,
+// oldest first, so lifetimes of sessions created before since are known;
+// only creates and removals at or after since are counted. Rows are sorted
+// by attached time, longest first.
+func Summarize(intervals []Interval, events []history.Event, since time.Time, keyOf func(session, configFile string) string) []Row {
+	rows := map[string]*Row{}
+	row := func(key string) *Row {
+		if r, ok := rows[key]; ok {
+			return r
+		}
+		r := &Row{Key: key}
+		rows[key] = r
+		return r
+	}
+
+	seen := map[string]map[string]bool{}
+	for _, iv := range intervals {
+		key := keyOf(iv.Session, iv.ConfigFile)
+		r := row(key)
+		r.Attached += iv.End.Sub(iv.Start)
+		if seen[key] == nil {
+			seen[key] = map[string]bool{}
+		}
+		if !seen[key][iv.Session] {
+			seen[key][iv.Session] = true
+			r.Sessions++
+		}
+	}
+
+	created := map[string]history.Event{}
+	totalLife := map[string]time.Duration{}
+	for _, e := range events {
+		inRange := since.IsZero() || !e.Time.Before(since)
+		switch {
+		case e.Action == history.ActionCreate:
+			created[e.Session] = e
+			if inRange {
+				row(keyOf(e.Session, e.ConfigFile)).Created++
+			}
+		case removalActions[e.Action]:
+			c, ok := created[e.Session]
+			delete(created, e.Session)
+			if !ok || !inRange {
+				continue
+			}
+			key := keyOf(c.Session, c.ConfigFile)
+			r := row(key)
+			r.Ended++
+			totalLife[key] += e.Time.Sub(c.Time)
+		}
+	}
+
+	out := make([]Row, 0, len(rows))
+	for key, r := range rows {
+		if r.Ended > 0 {
+			r.Lifetime = totalLife[key] / time.Duration(r.Ended)
+		}
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Attached != out[j].Attached {
+			return out[i].Attached > out[j].Attached
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
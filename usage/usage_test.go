@@ -0,0 +1,175 @@
+package usage
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"tmux-manager/history"
+)
+
+func setupTestHome(t *testing.T) (cleanup func()) {
+	t.Helper()
+	tmp := t.TempDir()
+	orig := os.Getenv("HOME")
+	os.Setenv("HOME", tmp)
+	return func() {
+		os.Setenv("HOME", orig)
+	}
+}
+
+var t0 = time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+
+// ─── Sample: attach → detach produces one interval ───────────────────────────
+
+func TestSample_AttachDetach(t *testing.T) {
+	defer setupTestHome(t)()
+
+	live := map[string]Observation{"api": {Attached: true, ConfigFile: "/p/api.yml"}}
+	if err := Sample(t0, live); err != nil {
+		t.Fatalf("Sample: %v", err)
+	}
+	// Still attached: nothing closes
+	Sample(t0.Add(30*time.Minute), live)
+
+	live["api"] = Observation{Attached: false, ConfigFile: "/p/api.yml"}
+	Sample(t0.Add(90*time.Minute), live)
+
+	got, err := Intervals(time.Time{}, t0.Add(10*time.Hour))
+	if err != nil {
+		t.Fatalf("Intervals: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected 1 interval, got %d: %v", len(got), got)
+	}
+	if d := got[0].End.Sub(got[0].Start); d != 90*time.Minute {
+		t.Errorf("interval length = %v, want 90m", d)
+	}
+	if got[0].ConfigFile != "/p/api.yml" {
+		t.Errorf("ConfigFile = %q", got[0].ConfigFile)
+	}
+}
+
+// ─── Sample: vanished session ends when last seen ────────────────────────────
+
+func TestSample_SessionVanished(t *testing.T) {
+	defer setupTestHome(t)()
+
+	Sample(t0, map[string]Observation{"api": {Attached: true}})
+	Sample(t0.Add(time.Hour), map[string]Observation{"api": {Attached: true}})
+	// tmux server died; next sample is much later and sees nothing
+	Sample(t0.Add(8*time.Hour), map[string]Observation{})
+
+	got, _ := Intervals(time.Time{}, t0.Add(10*time.Hour))
+	if len(got) != 1 || got[0].End.Sub(got[0].Start) != time.Hour {
+		t.Fatalf("expected a 1h interval ending at last sighting, got %v", got)
+	}
+}
+
+// ─── Intervals: open intervals run to last sighting and are clipped ─────────
+
+func TestIntervals_OpenAndClipped(t *testing.T) {
+	defer setupTestHome(t)()
+
+	Sample(t0, map[string]Observation{"web": {Attached: true}})
+	Sample(t0.Add(4*time.Hour), map[string]Observation{"web": {Attached: true}})
+
+	got, err := Intervals(t0.Add(time.Hour), t0.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("Intervals: %v", err)
+	}
+	if len(got) != 1 || got[0].End.Sub(got[0].Start) != 2*time.Hour {
+		t.Fatalf("expected open interval clipped to 2h, got %v", got)
+	}
+
+	// No sample since: the interval stops at the last sighting, not at until
+	stale, _ := Intervals(time.Time{}, t0.Add(48*time.Hour))
+	if len(stale) != 1 || stale[0].End.Sub(stale[0].Start) != 4*time.Hour {
+		t.Fatalf("expected a stale open interval to end at last sighting, got %v", stale)
+	}
+
+	none, _ := Intervals(t0.Add(5*time.Hour), t0.Add(5*time.Hour))
+	if len(none) != 0 {
+		t.Fatalf("empty range should yield nothing, got %v", none)
+	}
+}
+
+// ─── CloseAll / Enabled ──────────────────────────────────────────────────────
+
+func TestCloseAll(t *testing.T) {
+	defer setupTestHome(t)()
+
+	Sample(t0, map[string]Observation{"api": {Attached: true}})
+	Sample(t0.Add(time.Hour), map[string]Observation{"api": {Attached: true}})
+	if err := CloseAll(t0.Add(5 * time.Hour)); err != nil {
+		t.Fatalf("CloseAll: %v", err)
+	}
+	st, _ := loadOpen()
+	if len(st.Open) != 0 {
+		t.Fatalf("open intervals left: %v", st.Open)
+	}
+	got, _ := Intervals(time.Time{}, t0.Add(10*time.Hour))
+	if len(got) != 1 || got[0].End.Sub(got[0].Start) != time.Hour {
+		t.Fatalf("expected a 1h interval, got %v", got)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	defer setupTestHome(t)()
+
+	if Enabled() {
+		t.Fatal("tracking must be off until enabled")
+	}
+	if err := SetEnabled(true); err != nil || !Enabled() {
+		t.Fatalf("SetEnabled(true): %v, Enabled = %v", err, Enabled())
+	}
+	if err := SetEnabled(false); err != nil || Enabled() {
+		t.Fatalf("SetEnabled(false): %v, Enabled = %v", err, Enabled())
+	}
+	if err := SetEnabled(false); err != nil {
+		t.Fatalf("disabling twice: %v", err)
+	}
+}
+
+// ─── Summarize ───────────────────────────────────────────────────────────────
+
+func TestSummarize(t *testing.T) {
+	since := t0
+	intervals := []Interval{
+		{Session: "api", ConfigFile: "/p/api.yml", Start: t0, End: t0.Add(2 * time.Hour)},
+		{Session: "api-2", ConfigFile: "/p/api.yml", Start: t0, End: t0.Add(time.Hour)},
+		{Session: "scratch", Start: t0, End: t0.Add(30 * time.Minute)},
+	}
+	events := []history.Event{
+		// Created before the range, ended inside it: lifetime counts, create doesn't
+		{Time: t0.Add(-24 * time.Hour), Action: history.ActionCreate, Session: "api", ConfigFile: "/p/api.yml"},
+		{Time: t0.Add(3 * time.Hour), Action: history.ActionTerminate, Session: "api"},
+		{Time: t0.Add(time.Hour), Action: history.ActionCreate, Session: "api-2", ConfigFile: "/p/api.yml"},
+		{Time: t0.Add(2 * time.Hour), Action: history.ActionCreate, Session: "scratch"},
+		{Time: t0.Add(4 * time.Hour), Action: history.ActionCleanup, Session: "scratch"},
+	}
+	keyOf := func(session, configFile string) string {
+		if configFile != "" {
+			return configFile
+		}
+		return session
+	}
+
+	rows := Summarize(intervals, events, since, keyOf)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %v", rows)
+	}
+
+	api := rows[0]
+	if api.Key != "/p/api.yml" || api.Attached != 3*time.Hour || api.Sessions != 2 {
+		t.Errorf("unexpected api row: %+v", api)
+	}
+	if api.Created != 1 || api.Ended != 1 || api.Lifetime != 27*time.Hour {
+		t.Errorf("api creates/lifetime wrong: %+v", api)
+	}
+
+	scratch := rows[1]
+	if scratch.Key != "scratch" || scratch.Created != 1 || scratch.Lifetime != 2*time.Hour {
+		t.Errorf("unexpected scratch row: %+v", scratch)
+	}
+}